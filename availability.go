@@ -0,0 +1,185 @@
+package goar
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/tidwall/gjson"
+)
+
+var errNoSyncRecord = errors.New("c.DataSyncRecord(endOffset,1) is null")
+
+const (
+	DefaultAvailabilityPeers = 5
+	DefaultPeerTimeout       = 10 * time.Second
+)
+
+// NodeAvailability is whether a node stores the data of a transaction, according to its sync record
+type NodeAvailability struct {
+	Node   string
+	Stored bool
+	Err    error // the sync record could not be read
+}
+
+// AvailabilityReport is the replication of the data of a transaction over the client's node and a sample of peers
+type AvailabilityReport struct {
+	TxId     string
+	Nodes    []NodeAvailability // the node of the client first, the gateway that answered for a pooled client
+	Replicas int                // nodes storing the whole data
+	// Reseeded is set when the data was broadcast to the peers missing it, with the error of Broadcast.
+	// The sync records of the peers are only updated once they have stored the chunks, so they are not checked again.
+	Reseeded  bool
+	ReseedErr error
+}
+
+type AvailabilityOption func(o *availabilityOptions)
+
+type availabilityOptions struct {
+	peers       []string
+	sample      int
+	timeout     time.Duration
+	data        interface{}
+	minReplicas int
+}
+
+// WithAvailabilityPeers checks these peers instead of a sample of GetPeers
+func WithAvailabilityPeers(peers ...string) AvailabilityOption {
+	return func(o *availabilityOptions) { o.peers = peers }
+}
+
+// WithPeerSample sets the number of peers picked at random from GetPeers, DefaultAvailabilityPeers by default
+func WithPeerSample(n int) AvailabilityOption {
+	return func(o *availabilityOptions) { o.sample = n }
+}
+
+// WithPeerTimeout sets the timeout of the requests to each peer, DefaultPeerTimeout by default
+func WithPeerTimeout(timeout time.Duration) AvailabilityOption {
+	return func(o *availabilityOptions) { o.timeout = timeout }
+}
+
+// WithReseed broadcasts data, []byte or *os.File, to the checked peers missing it when fewer than minReplicas nodes store it
+func WithReseed(data interface{}, minReplicas int) AvailabilityOption {
+	return func(o *availabilityOptions) {
+		o.data = data
+		o.minReplicas = minReplicas
+	}
+}
+
+// VerifyDataAvailability checks the sync records of the client's node and of a sample of peers
+// to count the nodes storing the whole data of a transaction, see ExistTxData.
+// The transaction must be mined, its offset in the weave is unknown before: the error matches ErrNotFound.
+func (c *Client) VerifyDataAvailability(txId string, opts ...AvailabilityOption) (*AvailabilityReport, error) {
+	o := &availabilityOptions{sample: DefaultAvailabilityPeers, timeout: DefaultPeerTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	offset, err := c.getTransactionOffset(txId)
+	if err != nil {
+		return nil, err
+	}
+	peers := o.peers
+	if len(peers) == 0 && o.sample > 0 {
+		all, err := c.GetPeers()
+		if err != nil {
+			return nil, err
+		}
+		peers = samplePeers(all, o.sample)
+	}
+
+	report := &AvailabilityReport{TxId: txId, Nodes: make([]NodeAvailability, len(peers)+1)}
+	var wg sync.WaitGroup
+	check := func(i int, node string, client *Client) {
+		defer wg.Done()
+		stored, served, err := client.storesTxData(offset)
+		if errors.Is(err, errNoSyncRecord) { // nothing synced after the offset
+			err = nil
+		}
+		if i == 0 && served != "" { // a pooled client may have failed over to another gateway
+			node = served
+		}
+		report.Nodes[i] = NodeAvailability{Node: node, Stored: stored, Err: err}
+		if err != nil {
+			c.log().Debug("read sync record failed", "node", node, "arId", txId, "err", err)
+		}
+	}
+	wg.Add(len(report.Nodes))
+	go check(0, c.url, c)
+	for i, peer := range peers {
+		go check(i+1, peer, c.peerClient(peer, o.timeout))
+	}
+	wg.Wait()
+
+	missing := make([]string, 0, len(peers))
+	for i, node := range report.Nodes {
+		if node.Stored {
+			report.Replicas++
+		} else if i > 0 {
+			missing = append(missing, node.Node)
+		}
+	}
+	if size, _ := uploadDataSize(o.data); size > 0 && report.Replicas < o.minReplicas && len(missing) > 0 {
+		report.Reseeded = true
+		_, report.ReseedErr = c.Broadcast(txId, o.data, WithBroadcastNodes(o.minReplicas-report.Replicas), WithBroadcastPeers(missing...))
+	}
+	return report, nil
+}
+
+// VerifyAvailability checks the availability of the uploaded data with VerifyDataAvailability, once the
+// transaction is mined. With minReplicas > 0 the data of the uploader is broadcast to the peers missing it
+// when fewer nodes store it.
+func (tt *TransactionUploader) VerifyAvailability(minReplicas int, opts ...AvailabilityOption) (*AvailabilityReport, error) {
+	if minReplicas > 0 {
+		var data interface{} = tt.Data
+		if tt.DataReader != nil { // streamed from the file, not loaded in memory
+			data = tt.DataReader
+		}
+		opts = append(opts, WithReseed(data, minReplicas))
+	}
+	return tt.Client.VerifyDataAvailability(tt.Transaction.ID, opts...)
+}
+
+// storesTxData reports whether the sync record of the node covers the data at offset.
+// If the tx data has end offset 145 and size 10, GET /data_sync_record/145/1 returns {"<end>": "<start>"}
+// and the node has the tx data if start <= 145 - 10. The node that served the sync record is returned too.
+func (c *Client) storesTxData(offset *types.TransactionOffset) (bool, string, error) {
+	records, node, err := c.dataSyncRecordFrom(offset.Offset, 1)
+	if err != nil {
+		return false, node, err
+	}
+	if len(records) == 0 {
+		return false, node, errNoSyncRecord
+	}
+	start := ""
+	for _, val := range gjson.Parse(records[0]).Map() {
+		start = val.String()
+		break
+	}
+	startNum, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return false, node, err
+	}
+	endOffsetNum, err := strconv.ParseInt(offset.Offset, 10, 64)
+	if err != nil {
+		return false, node, err
+	}
+	sizeNum, err := strconv.ParseInt(offset.Size, 10, 64)
+	if err != nil {
+		return false, node, err
+	}
+	return startNum <= endOffsetNum-sizeNum, node, nil
+}
+
+func samplePeers(peers []string, n int) []string {
+	if n >= len(peers) {
+		return peers
+	}
+	sample := make([]string, 0, n)
+	for _, i := range rand.Perm(len(peers))[:n] {
+		sample = append(sample, peers[i])
+	}
+	return sample
+}
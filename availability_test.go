@@ -0,0 +1,145 @@
+package goar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockSyncNode serves the sync record {"<end>": "<start>"} of its data, and accepts the tx data when seeded
+type mockSyncNode struct {
+	t      *testing.T
+	tx     *types.Transaction
+	record string // empty for a failing node
+
+	lock   sync.Mutex
+	chunks int
+}
+
+func (n *mockSyncNode) serve() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case n.record == "":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.URL.Path == "/info":
+			w.Write([]byte(`{"network":"arweave.N.1"}`))
+		case r.URL.Path == "/tx/mock-tx/offset":
+			w.Write([]byte(`{"size":"` + n.tx.DataSize + `","offset":"1000999"}`))
+		case strings.HasPrefix(r.URL.Path, "/data_sync_record/1000999/1"):
+			w.Write([]byte(`[` + n.record + `]`))
+		case r.URL.Path == "/tx/mock-tx":
+			assert.NoError(n.t, json.NewEncoder(w).Encode(n.tx))
+		case r.URL.Path == "/chunk":
+			n.lock.Lock()
+			n.chunks++
+			n.lock.Unlock()
+			w.Write([]byte("OK"))
+		default:
+			n.t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestClient_VerifyDataAvailability(t *testing.T) {
+	data := make([]byte, 2*types.MAX_CHUNK_SIZE)
+	rand.Read(data)
+	tx := &types.Transaction{Format: 2, ID: "mock-tx", DataSize: strconv.Itoa(len(data))}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+	start := strconv.Itoa(1000999 - len(data))
+
+	stored := &mockSyncNode{t: t, tx: tx, record: `{"2000000":"` + start + `"}`}
+	partial := &mockSyncNode{t: t, tx: tx, record: `{"2000000":"1000000"}`}
+	failing := &mockSyncNode{t: t, tx: tx}
+	node, peer1, peer2, peer3 := stored.serve(), stored.serve(), partial.serve(), failing.serve()
+	defer node.Close()
+	defer peer1.Close()
+	defer peer2.Close()
+	defer peer3.Close()
+	peers := []string{
+		strings.TrimPrefix(peer1.URL, "http://"),
+		strings.TrimPrefix(peer2.URL, "http://"),
+		strings.TrimPrefix(peer3.URL, "http://"),
+	}
+
+	// the peers are requested with the retry policy and the hooks of the client
+	fast := RetryPolicy{MaxAttempts: 2, BaseDelay: time.Millisecond}
+	var failed int32
+	c := NewClient(node.URL)
+	c.SetRetryPolicy(fast)
+	c.SetHooks(Hooks{RequestFinish: func(ctx context.Context, req RequestInfo, res RequestResult) {
+		if strings.HasPrefix(req.Url, peer3.URL) {
+			atomic.AddInt32(&failed, 1)
+		}
+	}})
+	report, err := c.VerifyDataAvailability("mock-tx", WithAvailabilityPeers(peers...))
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&failed))
+	assert.Equal(t, 2, report.Replicas)
+	assert.Equal(t, 4, len(report.Nodes))
+	assert.Equal(t, node.URL, report.Nodes[0].Node)
+	assert.True(t, report.Nodes[1].Stored)
+	assert.False(t, report.Nodes[2].Stored)
+	assert.NoError(t, report.Nodes[2].Err)
+	assert.False(t, report.Nodes[3].Stored)
+	assert.ErrorIs(t, report.Nodes[3].Err, ErrBadGateway)
+	assert.False(t, report.Reseeded)
+
+	// the data is broadcast to a single peer missing it
+	uploader, err := CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	uploader.Data = data
+	report, err = uploader.VerifyAvailability(3, WithAvailabilityPeers(peers...))
+	assert.NoError(t, err)
+	assert.True(t, report.Reseeded)
+	assert.NoError(t, report.ReseedErr)
+	assert.Equal(t, 2, partial.chunks)
+
+	// the data of a file is streamed to the peer
+	f, err := os.CreateTemp(t.TempDir(), "data")
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(data)
+	assert.NoError(t, err)
+	uploader.Data, uploader.DataReader = nil, f
+	report, err = uploader.VerifyAvailability(3, WithAvailabilityPeers(peers...))
+	assert.NoError(t, err)
+	assert.True(t, report.Reseeded)
+	assert.NoError(t, report.ReseedErr)
+	assert.Equal(t, 4, partial.chunks)
+
+	// the pooled client fails over to the gateway storing the data
+	c = NewPoolClient([]string{peer3.URL, node.URL})
+	c.SetRetryPolicy(fast)
+	report, err = c.VerifyDataAvailability("mock-tx", WithAvailabilityPeers(peers...))
+	assert.NoError(t, err)
+	assert.Equal(t, node.URL, report.Nodes[0].Node)
+	assert.True(t, report.Nodes[0].Stored)
+
+	// the offset of the tx is unknown
+	c = NewClient(peer3.URL)
+	c.SetRetryPolicy(NoRetry)
+	_, err = c.VerifyDataAvailability("mock-tx", WithAvailabilityPeers(peers...))
+	assert.Error(t, err)
+}
+
+func TestSamplePeers(t *testing.T) {
+	peers := []string{"a", "b", "c", "d"}
+	assert.Equal(t, peers, samplePeers(peers, 5))
+	sample := samplePeers(peers, 2)
+	assert.Equal(t, 2, len(sample))
+	assert.NotEqual(t, sample[0], sample[1])
+	assert.Subset(t, peers, sample)
+}
@@ -0,0 +1,42 @@
+package goar
+
+import (
+	"fmt"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+)
+
+// VerifyBlockRange fetches the blocks [from, to] and the hash list of the same heights, and checks that they
+// form a valid chain with utils.VerifyBlockChain, so that headers served by an untrusted gateway can be trusted.
+// The error is only set when the blocks could not be fetched, the failed checks are in the report.
+func (c *Client) VerifyBlockRange(from, to int64) (*types.ChainReport, error) {
+	if from < 0 || from > to {
+		return nil, fmt.Errorf("invalid block range, from: %d, to: %d", from, to)
+	}
+	blocks := make([]*types.Block, 0, to-from+1)
+	for height := from; height <= to; height++ {
+		b, err := c.GetBlockByHeight(height)
+		if err != nil {
+			return nil, fmt.Errorf("get block %d: %w", height, err)
+		}
+		blocks = append(blocks, b)
+	}
+
+	hashList, err := c.GetBlockHashList(int(from), int(to))
+	if err != nil {
+		return nil, fmt.Errorf("get hash list: %w", err)
+	}
+	// gateways list the hashes from the newest block
+	if len(hashList) > 1 && hashList[0] == blocks[len(blocks)-1].IndepHash {
+		for i, j := 0, len(hashList)-1; i < j; i, j = i+1, j-1 {
+			hashList[i], hashList[j] = hashList[j], hashList[i]
+		}
+	}
+
+	report := utils.VerifyBlockChain(blocks, hashList)
+	if !report.Valid() {
+		c.log().Warn("invalid block range", "from", from, "to", to, "issues", len(report.Issues))
+	}
+	return report, nil
+}
@@ -0,0 +1,30 @@
+package goar
+
+import (
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_VerifyBlockRange(t *testing.T) {
+	chain := &mockChain{hashes: []string{"a0", "a1", "a2", "a3"}}
+	srv := chain.serve()
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	report, err := c.VerifyBlockRange(1, 3)
+	assert.NoError(t, err)
+	assert.True(t, report.Valid())
+	assert.Equal(t, []int64{1, 2, 3}, report.Unverified)
+
+	// the hash list does not match the blocks
+	chain.hashList = map[int]string{2: "b2"}
+	report, err = c.VerifyBlockRange(1, 3)
+	assert.NoError(t, err)
+	assert.False(t, report.Valid())
+	assert.Equal(t, []types.BlockIssue{{Height: 2, Check: types.CheckHashList, Expected: "b2", Actual: "a2"}}, report.Issues)
+
+	_, err = c.VerifyBlockRange(3, 1)
+	assert.Error(t, err)
+}
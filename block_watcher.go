@@ -0,0 +1,201 @@
+package goar
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/everFinance/goar/types"
+)
+
+// block event types
+const (
+	BlockApplied  = "applied"
+	BlockRollback = "rollback"
+)
+
+const (
+	DefaultBlockPollInterval = 30 * time.Second
+	DefaultBlockWindow       = 50
+)
+
+// BlockEvent is emitted by a BlockWatcher.
+// Rollbacks are emitted from the tip down, before the blocks of the new fork are applied.
+type BlockEvent struct {
+	Type      string       `json:"type"` // BlockApplied or BlockRollback
+	Height    int64        `json:"height"`
+	IndepHash string       `json:"indepHash"`
+	Block     *types.Block `json:"-"` // nil when rolling back a block passed to Resume
+}
+
+// BlockWatcher follows the chain of a gateway and emits its blocks in order, detecting the forks
+// with the previous_block of the new blocks and rolling back the orphaned ones
+type BlockWatcher struct {
+	c        *Client
+	next     int64 // first height to emit when nothing was applied yet, -1 for the current height
+	interval time.Duration
+	window   int
+
+	recent []BlockEvent // last applied blocks, by height
+	err    error
+}
+
+// NewBlockWatcher creates a watcher emitting the blocks from fromHeight, -1 to start at the current height
+func NewBlockWatcher(c *Client, fromHeight int64) *BlockWatcher {
+	return &BlockWatcher{
+		c:        c,
+		next:     fromHeight,
+		interval: DefaultBlockPollInterval,
+		window:   DefaultBlockWindow,
+	}
+}
+
+// Resume continues after the blocks applied by a previous watcher, usually persisted by the consumer, sorted by height.
+// Only the Height and IndepHash of recent are used. If the whole of recent was orphaned while the watcher
+// was stopped the depth of the fork is unknown, and the watcher stops with ErrReorgTooDeep.
+func (w *BlockWatcher) Resume(recent ...BlockEvent) {
+	w.recent = make([]BlockEvent, 0, len(recent))
+	for _, ev := range recent {
+		w.recent = append(w.recent, BlockEvent{Type: BlockApplied, Height: ev.Height, IndepHash: ev.IndepHash})
+	}
+}
+
+func (w *BlockWatcher) SetPollInterval(interval time.Duration) {
+	w.interval = interval
+}
+
+// SetWindow sets the number of recent blocks kept to handle forks, a deeper fork stops the watcher with ErrReorgTooDeep
+func (w *BlockWatcher) SetWindow(n int) {
+	if n < 1 {
+		n = 1
+	}
+	w.window = n
+}
+
+// Start follows the chain until ctx is done or a fork deeper than the window is found.
+// The returned channel is closed when the watcher stops, Err then returns the reason.
+func (w *BlockWatcher) Start(ctx context.Context) <-chan BlockEvent {
+	events := make(chan BlockEvent)
+	go func() {
+		defer close(events)
+		w.err = w.run(ctx, events)
+	}()
+	return events
+}
+
+// Err returns the error that stopped the watcher, only valid after the events channel is closed
+func (w *BlockWatcher) Err() error {
+	return w.err
+}
+
+func (w *BlockWatcher) run(ctx context.Context, events chan<- BlockEvent) error {
+	c := w.c.WithContext(ctx)
+	for {
+		if err := w.poll(ctx, c, events); err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, ErrReorgTooDeep) {
+				return err
+			}
+			c.log().Warn("block watcher poll failed", "err", err)
+		}
+		if err := c.sleep(w.interval); err != nil {
+			return err
+		}
+	}
+}
+
+// poll applies the blocks up to the current height of the gateway
+func (w *BlockWatcher) poll(ctx context.Context, c *Client, events chan<- BlockEvent) error {
+	info, err := c.GetInfo()
+	if err != nil {
+		return err
+	}
+
+	for {
+		if len(w.recent) == 0 {
+			if w.next < 0 {
+				w.next = info.Height
+			}
+			if w.next > info.Height {
+				return nil
+			}
+			b, err := c.GetBlockByHeight(w.next)
+			if err != nil {
+				return err
+			}
+			if err := w.apply(ctx, b, events); err != nil {
+				return err
+			}
+			continue
+		}
+
+		tip := w.recent[len(w.recent)-1]
+		if info.Height < tip.Height {
+			// the gateway is behind
+			return nil
+		}
+		if info.Height == tip.Height {
+			if info.Current == tip.IndepHash {
+				return nil
+			}
+			// the tip was replaced by a block of the same height
+			if err := w.rollback(ctx, events); err != nil {
+				return err
+			}
+			continue
+		}
+
+		b, err := c.GetBlockByHeight(tip.Height + 1)
+		if err != nil {
+			return err
+		}
+		if b.PreviousBlock != tip.IndepHash {
+			c.log().Info("fork detected", "height", b.Height, "indepHash", b.IndepHash, "previousBlock", b.PreviousBlock, "tip", tip.IndepHash)
+			if err := w.rollback(ctx, events); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := w.apply(ctx, b, events); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *BlockWatcher) apply(ctx context.Context, b *types.Block, events chan<- BlockEvent) error {
+	ev := BlockEvent{Type: BlockApplied, Height: b.Height, IndepHash: b.IndepHash, Block: b}
+	if err := emitBlock(ctx, events, ev); err != nil {
+		return err
+	}
+	w.recent = append(w.recent, ev)
+	if len(w.recent) > w.window {
+		w.recent = w.recent[len(w.recent)-w.window:]
+	}
+	return nil
+}
+
+// rollback removes the tip, which has been orphaned
+func (w *BlockWatcher) rollback(ctx context.Context, events chan<- BlockEvent) error {
+	tip := w.recent[len(w.recent)-1]
+	if len(w.recent) == 1 {
+		return fmt.Errorf("%w: block %d %s orphaned", ErrReorgTooDeep, tip.Height, tip.IndepHash)
+	}
+	tip.Type = BlockRollback
+	if err := emitBlock(ctx, events, tip); err != nil {
+		return err
+	}
+	w.recent = w.recent[:len(w.recent)-1]
+	return nil
+}
+
+func emitBlock(ctx context.Context, events chan<- BlockEvent, ev BlockEvent) error {
+	select {
+	case events <- ev:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("block %d not emitted: %w", ev.Height, ctx.Err())
+	}
+}
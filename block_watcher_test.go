@@ -0,0 +1,152 @@
+package goar
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockChain serves /info, /block/height/{n} and /hash_list/{from}/{to} from a chain of indep_hashes that can be forked
+type mockChain struct {
+	sync.Mutex
+	hashes   []string
+	hashList map[int]string // overrides the hash list of a height
+}
+
+func (m *mockChain) fork(height int64, hashes ...string) {
+	m.Lock()
+	defer m.Unlock()
+	m.hashes = append(m.hashes[:height], hashes...)
+}
+
+func (m *mockChain) serve() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		m.Lock()
+		defer m.Unlock()
+		tip := int64(len(m.hashes) - 1)
+		if r.URL.Path == "/info" {
+			json.NewEncoder(w).Encode(types.NetworkInfo{Height: tip, Current: m.hashes[tip]})
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/hash_list/") {
+			// newest first, like the gateways
+			var from, to int
+			fmt.Sscanf(r.URL.Path, "/hash_list/%d/%d", &from, &to)
+			hashes := make([]string, 0)
+			for i := to; i >= from && i < len(m.hashes); i-- {
+				if hash, ok := m.hashList[i]; ok {
+					hashes = append(hashes, hash)
+				} else {
+					hashes = append(hashes, m.hashes[i])
+				}
+			}
+			json.NewEncoder(w).Encode(hashes)
+			return
+		}
+		height, err := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/block/height/"), 10, 64)
+		if err != nil || height > tip {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		b := types.Block{Height: height, IndepHash: m.hashes[height]}
+		if height > 0 {
+			b.PreviousBlock = m.hashes[height-1]
+		}
+		json.NewEncoder(w).Encode(b)
+	}))
+}
+
+func nextBlockEvent(t *testing.T, events <-chan BlockEvent) BlockEvent {
+	select {
+	case ev := <-events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no block event")
+	}
+	return BlockEvent{}
+}
+
+func TestBlockWatcher(t *testing.T) {
+	chain := &mockChain{hashes: []string{"a0", "a1", "a2", "a3"}}
+	srv := chain.serve()
+	defer srv.Close()
+
+	w := NewBlockWatcher(NewClient(srv.URL), 1)
+	w.SetPollInterval(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+	events := w.Start(ctx)
+
+	for _, hash := range []string{"a1", "a2", "a3"} {
+		ev := nextBlockEvent(t, events)
+		assert.Equal(t, BlockApplied, ev.Type)
+		assert.Equal(t, hash, ev.IndepHash)
+		assert.Equal(t, hash, ev.Block.IndepHash)
+	}
+
+	// a2 and a3 are orphaned
+	chain.fork(2, "b2", "b3", "b4")
+	expected := []BlockEvent{
+		{Type: BlockRollback, Height: 3, IndepHash: "a3"},
+		{Type: BlockRollback, Height: 2, IndepHash: "a2"},
+		{Type: BlockApplied, Height: 2, IndepHash: "b2"},
+		{Type: BlockApplied, Height: 3, IndepHash: "b3"},
+		{Type: BlockApplied, Height: 4, IndepHash: "b4"},
+	}
+	for _, exp := range expected {
+		ev := nextBlockEvent(t, events)
+		assert.Equal(t, exp.Type, ev.Type)
+		assert.Equal(t, exp.Height, ev.Height)
+		assert.Equal(t, exp.IndepHash, ev.IndepHash)
+	}
+
+	// the tip is replaced by a block of the same height
+	chain.fork(4, "c4")
+	ev := nextBlockEvent(t, events)
+	assert.Equal(t, BlockRollback, ev.Type)
+	assert.Equal(t, "b4", ev.IndepHash)
+	ev = nextBlockEvent(t, events)
+	assert.Equal(t, BlockApplied, ev.Type)
+	assert.Equal(t, "c4", ev.IndepHash)
+
+	cancel()
+	for range events {
+	}
+	assert.ErrorIs(t, w.Err(), context.Canceled)
+}
+
+func TestBlockWatcher_Resume(t *testing.T) {
+	chain := &mockChain{hashes: []string{"a0", "a1", "b2", "b3"}}
+	srv := chain.serve()
+	defer srv.Close()
+
+	// a2 was orphaned while the watcher was stopped
+	w := NewBlockWatcher(NewClient(srv.URL), -1)
+	w.SetPollInterval(10 * time.Millisecond)
+	w.Resume(BlockEvent{Height: 1, IndepHash: "a1"}, BlockEvent{Height: 2, IndepHash: "a2"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := w.Start(ctx)
+	ev := nextBlockEvent(t, events)
+	assert.Equal(t, BlockRollback, ev.Type)
+	assert.Equal(t, "a2", ev.IndepHash)
+	assert.Nil(t, ev.Block)
+	assert.Equal(t, "b2", nextBlockEvent(t, events).IndepHash)
+	assert.Equal(t, "b3", nextBlockEvent(t, events).IndepHash)
+
+	// the whole window was orphaned
+	w = NewBlockWatcher(NewClient(srv.URL), -1)
+	w.Resume(BlockEvent{Height: 2, IndepHash: "a2"})
+	for range w.Start(ctx) {
+	}
+	assert.ErrorIs(t, w.Err(), ErrReorgTooDeep)
+}
@@ -2,6 +2,7 @@ package goar
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,45 +18,87 @@ import (
 	"sync"
 	"time"
 
-	"github.com/inconshreveable/log15"
 	"github.com/panjf2000/ants/v2"
 	"github.com/tidwall/gjson"
-	"gopkg.in/h2non/gentleman.v2"
 
 	"github.com/everFinance/goar/types"
 	"github.com/everFinance/goar/utils"
 )
 
-var log = log15.New("module", "goar")
+// Logger is the structured logger of the package, see SetLogger and utils.SetLogger
+type Logger = utils.Logger
 
 // arweave HTTP API: https://docs.arweave.org/developers/server/http-api
 
 type Client struct {
-	client *http.Client
-	url    string
+	client  *http.Client
+	url     string
+	header  http.Header // sent with every request
+	ctx     context.Context
+	pool    *gatewayPool
+	retry   RetryPolicy
+	limiter *RateLimiter
+
+	verifyChunks bool
+	tempDir      string
+	progress     ProgressFunc
+	logger       Logger
+	hooks        Hooks
 }
 
+// NewClient panics on an invalid proxy url, use NewClientWithOptions to get an error instead
 func NewClient(nodeUrl string, proxyUrl ...string) *Client {
-	httpClient := http.DefaultClient
+	var opts []ClientOption
 	// if exist proxy url
 	if len(proxyUrl) > 0 {
-		pUrl := proxyUrl[0]
-		proxyUrl, err := url.Parse(pUrl)
-		if err != nil {
-			log.Error("url parse", "error", err)
-			panic(err)
-		}
-		tr := &http.Transport{Proxy: http.ProxyURL(proxyUrl)}
-		httpClient = &http.Client{Transport: tr}
+		opts = append(opts, WithProxy(proxyUrl[0]))
 	}
+	c, err := NewClientWithOptions(nodeUrl, opts...)
+	if err != nil {
+		utils.DefaultLogger().Error("new client", "error", err)
+		panic(err)
+	}
+	return c
+}
 
-	return &Client{client: httpClient, url: nodeUrl}
+// NewPoolClient creates a client backed by several gateways or nodes.
+// Every request is routed to the healthiest endpoint, measured by latency, error rate and 429 responses,
+// and fails over to the next one on network errors, 429, 5xx and, for reads, 404.
+func NewPoolClient(nodeUrls []string, proxyUrl ...string) *Client {
+	if len(nodeUrls) == 0 {
+		panic("nodeUrls can not be empty")
+	}
+	opts := []ClientOption{WithGateways(nodeUrls[1:]...)}
+	if len(proxyUrl) > 0 {
+		opts = append(opts, WithProxy(proxyUrl[0]))
+	}
+	c, err := NewClientWithOptions(nodeUrls[0], opts...)
+	if err != nil {
+		utils.DefaultLogger().Error("new pool client", "error", err)
+		panic(err)
+	}
+	return c
 }
 
 func NewTempConn() *Client {
 	transport := http.Transport{DisableKeepAlives: true}
 	cli := &http.Client{Transport: &transport}
-	return &Client{client: cli}
+	// peers are tried one after another, moving on is cheaper than retrying
+	return &Client{client: cli, retry: NoRetry}
+}
+
+// peerClient returns a client of the peer, with the context, retry policy, rate limiter, logger and hooks of c.
+// The header of c, eg: the API key of a paid gateway, is not sent to the peer.
+func (c *Client) peerClient(peer string, timeout time.Duration) *Client {
+	p := NewTempConn()
+	p.SetTempConnUrl("http://" + peer)
+	p.SetTimeout(timeout)
+	p.ctx = c.ctx
+	p.retry = c.retry
+	p.limiter = c.limiter
+	p.logger = c.logger
+	p.hooks = c.hooks
+	return p
 }
 
 func (c *Client) SetTempConnUrl(url string) {
@@ -66,37 +109,116 @@ func (c *Client) SetTimeout(timeout time.Duration) {
 	c.client.Timeout = timeout
 }
 
-func (c *Client) GetInfo() (info *types.NetworkInfo, err error) {
-	body, code, err := c.httpGet("info")
-	if code == 429 {
-		return nil, ErrRequestLimit
+// SetRetryPolicy sets how failed requests are retried, use NoRetry to disable retries
+func (c *Client) SetRetryPolicy(policy RetryPolicy) {
+	c.retry = policy
+}
+
+// RetryPolicy returns the retry policy of the client
+func (c *Client) RetryPolicy() RetryPolicy {
+	return c.retry.orDefault(DefaultRetryPolicy())
+}
+
+// SetChunkVerification enables or disables the verification of downloaded chunks against the data_root of the tx.
+// It is enabled by default.
+func (c *Client) SetChunkVerification(enable bool) {
+	c.verifyChunks = enable
+}
+
+// SetTempDir sets the directory of the temp files created by the stream methods, see utils.SetTempDir for the default
+func (c *Client) SetTempDir(dir string) {
+	c.tempDir = dir
+}
+
+func (c *Client) createTemp(pattern string) (*os.File, error) {
+	return utils.CreateTemp(c.tempDir, pattern)
+}
+
+// SetProgress sets the callback receiving the progress of the chunk downloads and BroadcastData,
+// uploaders created with the client report to it too
+func (c *Client) SetProgress(fn ProgressFunc) {
+	c.progress = fn
+}
+
+func (c *Client) downloadProgress(info *txDataInfo) *progressTracker {
+	totalChunks := int((info.size + types.MAX_CHUNK_SIZE - 1) / types.MAX_CHUNK_SIZE)
+	return newProgressTracker(c.progress, OpDownload, info.id, info.size, totalChunks)
+}
+
+// SetLogger sets the logger of the client, utils.DefaultLogger() is used if it is not set
+func (c *Client) SetLogger(l Logger) {
+	c.logger = l
+}
+
+func (c *Client) log() Logger {
+	if c.logger != nil {
+		return c.logger
 	}
-	if err != nil {
-		return nil, ErrBadGateway
+	return utils.DefaultLogger()
+}
+
+// SetRateLimiter sets the client side rate limiter, nil disables rate limiting
+func (c *Client) SetRateLimiter(limiter *RateLimiter) {
+	c.limiter = limiter
+}
+
+// RateLimiter returns the rate limiter of the client, nil if rate limiting is disabled
+func (c *Client) RateLimiter() *RateLimiter {
+	return c.limiter
+}
+
+func (c *Client) withRetryPolicy(policy RetryPolicy) *Client {
+	c2 := *c
+	c2.retry = policy
+	return &c2
+}
+
+// Endpoints returns the health of every gateway of a pooled client, or nil for a single node client
+func (c *Client) Endpoints() []EndpointStats {
+	if c.pool == nil {
+		return nil
 	}
-	if code != 200 {
-		return nil, fmt.Errorf("get info error: %s", string(body))
+	return c.pool.stats()
+}
+
+// WithContext returns a shallow copy of the client whose requests are bound to ctx.
+// Cancelling ctx aborts in-flight requests and stops chunk download/upload loops.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if ctx == nil {
+		panic("nil context")
+	}
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
+}
+
+// Context returns the client's context, context.Background() if none was set
+func (c *Client) Context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+func (c *Client) GetInfo() (info *types.NetworkInfo, err error) {
+	resp := c.get("info")
+	if !resp.ok(200) {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
 	info = &types.NetworkInfo{}
-	err = json.Unmarshal(body, info)
+	err = json.Unmarshal(resp.body, info)
 	return
 }
 
 func (c *Client) GetPeers() ([]string, error) {
-	body, code, err := c.httpGet("peers")
-	if code == 429 {
-		return nil, ErrRequestLimit
-	}
-	if err != nil {
-		return nil, ErrBadGateway
-	}
-	if code != 200 {
-		return nil, fmt.Errorf("get peers error: %s", string(body))
+	resp := c.get("peers")
+	if !resp.ok(200) {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
 	peers := make([]string, 0)
-	err = json.Unmarshal(body, &peers)
+	err := json.Unmarshal(resp.body, &peers)
 	if err != nil {
 		return nil, err
 	}
@@ -115,73 +237,50 @@ func (c *Client) GetPeers() ([]string, error) {
 
 // GetTransactionByID status: Pending/Invalid hash/overspend
 func (c *Client) GetTransactionByID(id string) (tx *types.Transaction, err error) {
-	body, statusCode, err := c.httpGet(fmt.Sprintf("tx/%s", id))
-	if err != nil {
-		return nil, ErrBadGateway
+	resp := c.get(fmt.Sprintf("tx/%s", id))
+	if resp.err != nil {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
 		// json unmarshal
 		tx = &types.Transaction{}
-		err = json.Unmarshal(body, tx)
+		err = json.Unmarshal(resp.body, tx)
 		return
-	case 202:
-		return nil, ErrPendingTx
 	case 400:
-		return nil, ErrInvalidId
-	case 404:
-		return nil, ErrNotFound
-	case 429:
-		return nil, ErrRequestLimit
+		return nil, resp.apiError(ErrInvalidId)
 	default:
-		return nil, ErrBadGateway
+		return nil, resp.apiError(ErrBadGateway)
 	}
 }
 
 // GetTransactionStatus
 func (c *Client) GetTransactionStatus(id string) (*types.TxStatus, error) {
-	body, code, err := c.httpGet(fmt.Sprintf("tx/%s/status", id))
-	if err != nil {
-		return nil, ErrBadGateway
+	resp := c.get(fmt.Sprintf("tx/%s/status", id))
+	if !resp.ok(200) {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
-	switch code {
-	case 200:
-		// json unmarshal
-		txStatus := &types.TxStatus{}
-		err = json.Unmarshal(body, txStatus)
-		return txStatus, err
-	case 202:
-		return nil, ErrPendingTx
-	case 404:
-		return nil, ErrNotFound
-	case 429:
-		return nil, ErrRequestLimit
-	default:
-		return nil, ErrBadGateway
-	}
+	// json unmarshal
+	txStatus := &types.TxStatus{}
+	err := json.Unmarshal(resp.body, txStatus)
+	return txStatus, err
 }
 
 func (c *Client) GetTransactionField(id string, field string) (string, error) {
-	body, statusCode, err := c.httpGet(fmt.Sprintf("tx/%v/%v", id, field))
-	if err != nil {
-		return "", ErrBadGateway
+	resp := c.get(fmt.Sprintf("tx/%v/%v", id, field))
+	if resp.err != nil {
+		return "", resp.apiError(ErrBadGateway)
 	}
 
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
-		return string(body), nil
-	case 202:
-		return "", ErrPendingTx
+		return string(resp.body), nil
 	case 400:
-		return "", ErrInvalidId
-	case 404:
-		return "", ErrNotFound
-	case 429:
-		return "", ErrRequestLimit
+		return "", resp.apiError(ErrInvalidId)
 	default:
-		return "", ErrBadGateway
+		return "", resp.apiError(ErrBadGateway)
 	}
 }
 
@@ -207,28 +306,22 @@ func (c *Client) GetTransactionData(id string, extension ...string) ([]byte, err
 	if extension != nil {
 		urlPath = urlPath + "." + extension[0]
 	}
-	data, statusCode, err := c.httpGet(urlPath)
-	if err != nil {
-		return nil, fmt.Errorf("httpGet error: %v", err)
+	resp := c.get(urlPath)
+	if resp.err != nil {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
 	// When data is bigger than 12MiB statusCode == 400 NOTE: Data bigger than that has to be downloaded chunk by chunk.
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
-		if len(data) == 0 {
+		if len(resp.body) == 0 {
 			return c.DownloadChunkData(id)
 		}
-		return data, nil
+		return resp.body, nil
 	case 400:
 		return c.DownloadChunkData(id)
-	case 202:
-		return nil, ErrPendingTx
-	case 404:
-		return nil, ErrNotFound
-	case 429:
-		return nil, ErrRequestLimit
 	default:
-		return nil, ErrBadGateway
+		return nil, resp.apiError(ErrBadGateway)
 	}
 }
 
@@ -237,138 +330,124 @@ func (c *Client) GetTransactionDataStream(id string, extension ...string) (*os.F
 	if extension != nil {
 		urlPath = urlPath + "." + extension[0]
 	}
-	data, statusCode, err := c.httpGet(urlPath)
-	if err != nil {
-		return nil, fmt.Errorf("httpGet error: %v", err)
+	resp := c.get(urlPath)
+	if resp.err != nil {
+		return nil, resp.apiError(ErrBadGateway)
 	}
 
 	// When data is bigger than 12MiB statusCode == 400 NOTE: Data bigger than that has to be downloaded chunk by chunk.
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
-		if len(data) == 0 {
+		if len(resp.body) == 0 {
 			return c.DownloadChunkDataStream(id)
 		}
-		dataFile, err := os.CreateTemp(".", "arTxData-")
+		dataFile, err := c.createTemp("arTxData-")
 		if err != nil {
 			return nil, err
 		}
-		_, err = dataFile.Write(data)
+		_, err = dataFile.Write(resp.body)
 		return dataFile, err
 	case 400:
 		return c.DownloadChunkDataStream(id)
-	case 202:
-		return nil, ErrPendingTx
-	case 404:
-		return nil, ErrNotFound
-	case 429:
-		return nil, ErrRequestLimit
 	default:
-		return nil, ErrBadGateway
+		return nil, resp.apiError(ErrBadGateway)
 	}
 }
 
 // GetTransactionDataByGateway
 func (c *Client) GetTransactionDataByGateway(id string) (body []byte, err error) {
 	urlPath := fmt.Sprintf("/%v/%v", id, "data")
-	body, statusCode, err := c.httpGet(urlPath)
-	if err != nil {
-		return nil, fmt.Errorf("httpGet error: %v", err)
+	resp := c.get(urlPath)
+	if resp.err != nil {
+		return nil, resp.apiError(ErrBadGateway)
 	}
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
-		if len(body) == 0 {
+		if len(resp.body) == 0 {
 			return c.DownloadChunkData(id)
 		}
-		return body, nil
+		return resp.body, nil
 	case 400:
 		return c.DownloadChunkData(id)
-	case 202:
-		return nil, ErrPendingTx
-	case 404:
-		return nil, ErrNotFound
 	case 410:
-		return nil, ErrInvalidId
-	case 429:
-		return nil, ErrRequestLimit
+		return nil, resp.apiError(ErrInvalidId)
 	default:
-		return nil, ErrBadGateway
+		return nil, resp.apiError(ErrBadGateway)
 	}
 }
 
 func (c *Client) GetTransactionDataStreamByGateway(id string) (*os.File, error) {
 	urlPath := fmt.Sprintf("/%v/%v", id, "data")
-	body, statusCode, err := c.httpGet(urlPath)
-	if err != nil {
-		return nil, fmt.Errorf("httpGet error: %v", err)
+	resp := c.get(urlPath)
+	if resp.err != nil {
+		return nil, resp.apiError(ErrBadGateway)
 	}
-	switch statusCode {
+	switch resp.statusCode {
 	case 200:
-		if len(body) == 0 {
+		if len(resp.body) == 0 {
 			return c.DownloadChunkDataStream(id)
 		}
-		dataFile, err := os.CreateTemp(".", "arTxData-")
+		dataFile, err := c.createTemp("arTxData-")
 		if err != nil {
 			return nil, err
 		}
-		_, err = dataFile.Write(body)
+		_, err = dataFile.Write(resp.body)
 		return dataFile, err
 	case 400:
 		return c.DownloadChunkDataStream(id)
-	case 202:
-		return nil, ErrPendingTx
-	case 404:
-		return nil, ErrNotFound
 	case 410:
-		return nil, ErrInvalidId
-	case 429:
-		return nil, ErrRequestLimit
+		return nil, resp.apiError(ErrInvalidId)
 	default:
-		return nil, ErrBadGateway
+		return nil, resp.apiError(ErrBadGateway)
 	}
 }
 
 func (c *Client) GetTransactionPrice(dataSize int, target *string) (reward int64, err error) {
-	url := fmt.Sprintf("price/%d", dataSize)
+	_target := ""
 	if target != nil {
-		url = fmt.Sprintf("%v/%v", url, *target)
-	}
-
-	body, code, err := c.httpGet(url)
-	if code == 429 {
-		return 0, ErrRequestLimit
+		_target = *target
 	}
+	price, err := c.GetTransactionPriceBig(int64(dataSize), _target)
 	if err != nil {
-		return
+		return 0, err
 	}
-	if code != 200 {
-		return 0, fmt.Errorf("get reward error: %s", string(body))
+	if !price.IsInt64() {
+		return 0, fmt.Errorf("reward %s overflows int64, use GetTransactionPriceBig", price)
 	}
+	return price.Int64(), nil
+}
 
-	reward, err = strconv.ParseInt(string(body), 10, 64)
-	if err != nil {
-		return
+// GetTransactionPriceBig returns the reward quoted by the gateway for dataSize bytes,
+// including the fee of a new wallet when target, optional, does not exist yet
+func (c *Client) GetTransactionPriceBig(dataSize int64, target string) (*big.Int, error) {
+	url := fmt.Sprintf("price/%d", dataSize)
+	if target != "" {
+		url = fmt.Sprintf("%v/%v", url, target)
 	}
 
+	resp := c.get(url)
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
+	}
+
+	reward, ok := new(big.Int).SetString(string(resp.body), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid reward: %s", resp.body)
+	}
 	// reward can not be 0
-	if reward <= 0 {
-		err = errors.New("reward must more than 0")
+	if reward.Sign() <= 0 {
+		return nil, errors.New("reward must more than 0")
 	}
-	return
+	return reward, nil
 }
 
 func (c *Client) GetTransactionAnchor() (anchor string, err error) {
-	body, code, err := c.httpGet("tx_anchor")
-	if code == 429 {
-		return "", ErrRequestLimit
-	}
-	if err != nil {
-		return
-	}
-	if code != 200 {
-		return "", fmt.Errorf("get tx anchor err: %s", string(body))
+	resp := c.get("tx_anchor")
+	if !resp.ok(200) {
+		return "", resp.apiError(nil)
 	}
 
-	anchor = string(body)
+	anchor = string(resp.body)
 	return
 }
 
@@ -378,24 +457,36 @@ func (c *Client) SubmitTransaction(tx *types.Transaction) (status string, code i
 		return
 	}
 
-	body, statusCode, err := c.httpPost("tx", by)
-	status = string(body)
-	code = statusCode
+	resp := c.post("tx", by)
+	status = string(resp.body)
+	code = resp.statusCode
+	if resp.err != nil {
+		err = resp.apiError(nil)
+	}
 	return
 }
 
 func (c *Client) SubmitChunks(gc *types.GetChunk) (status string, code int, err error) {
-	byteGc, err := gc.Marshal()
+	resp, err := c.submitChunks(gc)
 	if err != nil {
 		return
 	}
-
-	var body []byte
-	body, code, err = c.httpPost("chunk", byteGc)
-	status = string(body)
+	status = string(resp.body)
+	code = resp.statusCode
+	if resp.err != nil {
+		err = resp.apiError(nil)
+	}
 	return
 }
 
+func (c *Client) submitChunks(gc *types.GetChunk) (*response, error) {
+	byteGc, err := gc.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return c.post("chunk", byteGc), nil
+}
+
 // Arql is Deprecated, recommended to use GraphQL
 func (c *Client) Arql(arql string) (ids []string, err error) {
 	body, _, err := c.httpPost("arql", []byte(arql))
@@ -414,23 +505,16 @@ func (c *Client) GraphQL(query string) ([]byte, error) {
 	}
 
 	// query from http client
-	data, statusCode, err := c.httpPost("graphql", byQuery)
-	if statusCode == 429 {
-		return nil, ErrRequestLimit
-	}
-	if err != nil {
-		return nil, err
-	}
-
-	if statusCode != http.StatusOK {
-		return nil, fmt.Errorf(string(data))
+	resp := c.post("graphql", byQuery)
+	if !resp.ok(http.StatusOK) {
+		return nil, resp.apiError(nil)
 	}
 
 	// unwrap data
 	res := struct {
 		Data interface{}
 	}{}
-	if err := json.Unmarshal(data, &res); err != nil {
+	if err := json.Unmarshal(resp.body, &res); err != nil {
 		return nil, err
 	}
 
@@ -449,18 +533,12 @@ func (c *Client) GetWalletBalance(address string) (arAmount *big.Float, err erro
 }
 
 func (c *Client) GetWalletWinstonBalance(address string) (arAmount *big.Int, err error) {
-	body, code, err := c.httpGet(fmt.Sprintf("wallet/%s/balance", address))
-	if code == 429 {
-		return nil, ErrRequestLimit
-	}
-	if err != nil {
-		return
-	}
-	if code != 200 {
-		return nil, fmt.Errorf("get balance error: %s", string(body))
+	resp := c.get(fmt.Sprintf("wallet/%s/balance", address))
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
 	}
 
-	winstomStr := string(body)
+	winstomStr := string(resp.body)
 	winstom, ok := new(big.Int).SetString(winstomStr, 10)
 	if !ok {
 		err = fmt.Errorf("invalid balance: %v", winstomStr)
@@ -471,166 +549,236 @@ func (c *Client) GetWalletWinstonBalance(address string) (arAmount *big.Int, err
 }
 
 func (c *Client) GetLastTransactionID(address string) (id string, err error) {
-	body, code, err := c.httpGet(fmt.Sprintf("wallet/%s/last_tx", address))
-	if code == 429 {
-		return "", ErrRequestLimit
-	}
-	if err != nil {
-		return
-	}
-	if code != 200 {
-		return "", fmt.Errorf("get last id error: %s", string(body))
+	resp := c.get(fmt.Sprintf("wallet/%s/last_tx", address))
+	if !resp.ok(200) {
+		return "", resp.apiError(nil)
 	}
 
-	id = string(body)
+	id = string(resp.body)
 	return
 }
 
 // Block
 func (c *Client) GetBlockByID(id string) (block *types.Block, err error) {
-	body, code, err := c.httpGet(fmt.Sprintf("block/hash/%s", id))
-	if err != nil {
-		return
+	resp := c.get(fmt.Sprintf("block/hash/%s", id))
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
 	}
-	if code == 429 {
-		return nil, ErrRequestLimit
-	}
-
-	if code != 200 {
-		return nil, fmt.Errorf("get block by id error: %s", string(body))
-	}
-	block, err = utils.DecodeBlock(string(body))
+	block, err = utils.DecodeBlock(string(resp.body))
 	return
 }
 
 func (c *Client) GetBlockByHeight(height int64) (block *types.Block, err error) {
-	body, code, err := c.httpGet(fmt.Sprintf("block/height/%d", height))
-	if err != nil {
-		return
+	resp := c.get(fmt.Sprintf("block/height/%d", height))
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
 	}
-	if code == 429 {
-		return nil, ErrRequestLimit
-	}
-
-	if code != 200 {
-		return nil, fmt.Errorf("get block by height error: %s", string(body))
-	}
-	block, err = utils.DecodeBlock(string(body))
+	block, err = utils.DecodeBlock(string(resp.body))
 	return
 }
 
-func (c *Client) httpGet(_path string) (body []byte, statusCode int, err error) {
-	u, err := url.Parse(c.url)
-	if err != nil {
-		return
+// about chunk
+
+func (c *Client) getChunk(offset int64) (*types.TransactionChunk, error) {
+	chunk, _, err := c.getChunkFrom(offset)
+	return chunk, err
+}
+
+// getChunkFrom also returns the node that served the chunk
+func (c *Client) getChunkFrom(offset int64) (*types.TransactionChunk, string, error) {
+	_path := "chunk/" + strconv.FormatInt(offset, 10)
+	resp := c.get(_path)
+	if !resp.ok(200) {
+		return nil, resp.node, resp.apiError(ErrBadGateway)
 	}
 
-	u.Path = path.Join(u.Path, _path)
+	txChunk := &types.TransactionChunk{}
+	if err := json.Unmarshal(resp.body, txChunk); err != nil {
+		return nil, resp.node, err
+	}
+	return txChunk, resp.node, nil
+}
 
-	resp, err := c.client.Get(u.String())
+func (c *Client) getChunkData(offset int64) ([]byte, error) {
+	chunk, err := c.getChunk(offset)
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return utils.Base64Decode(chunk.Chunk)
+}
 
-	statusCode = resp.StatusCode
-	body, err = io.ReadAll(resp.Body)
-	return
+// txDataInfo locates the data of a transaction in the weave
+type txDataInfo struct {
+	id          string
+	size        int64
+	startOffset int64  // weave offset of the first byte
+	endOffset   int64  // weave offset of the last byte
+	dataRoot    []byte // nil when chunks are not verified
 }
 
-func (c *Client) httpPost(_path string, payload []byte) (body []byte, statusCode int, err error) {
-	u, err := url.Parse(c.url)
+func (c *Client) getTxDataInfo(id string) (*txDataInfo, error) {
+	offsetResponse, err := c.getTransactionOffset(id)
 	if err != nil {
-		return
+		return nil, err
+	}
+	size, err := strconv.ParseInt(offsetResponse.Size, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	endOffset, err := strconv.ParseInt(offsetResponse.Offset, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	info := &txDataInfo{
+		id:          id,
+		size:        size,
+		startOffset: endOffset - size + 1,
+		endOffset:   endOffset,
+	}
+	if !c.verifyChunks {
+		return info, nil
 	}
 
-	u.Path = path.Join(u.Path, _path)
-
-	resp, err := c.client.Post(u.String(), "application/json", bytes.NewReader(payload))
+	dataRoot, err := c.GetTransactionField(id, "data_root")
 	if err != nil {
-		return
+		return nil, err
 	}
-	defer resp.Body.Close()
+	if dataRoot == "" { // format 1 tx
+		c.log().Warn("tx has no data_root, chunks can not be verified", "arId", id)
+		return info, nil
+	}
+	info.dataRoot, err = utils.Base64Decode(dataRoot)
+	return info, err
+}
 
-	statusCode = resp.StatusCode
-	body, err = io.ReadAll(resp.Body)
-	return
+// getTxChunkData downloads the chunk at the weave offset and verifies it against the data_root of the tx.
+// Invalid chunks are downloaded again according to the retry policy, from another gateway for a pooled client.
+func (c *Client) getTxChunkData(info *txDataInfo, offset int64) ([]byte, error) {
+	data, _, err := c.getTxChunk(info, offset)
+	return data, err
 }
 
-// about chunk
+// getTxChunk is getTxChunkData, it also returns the position of the chunk in the tx data
+func (c *Client) getTxChunk(info *txDataInfo, offset int64) (data []byte, chunkStart int64, err error) {
+	start := time.Now()
+	attempt := 1
+	hook := func(data []byte, err error) {
+		c.chunkHook(ChunkEvent{Op: OpDownload, TxId: info.id, Offset: offset, Size: len(data), Attempt: attempt, Duration: time.Since(start), Err: err})
+	}
 
-func (c *Client) getChunk(offset int64) (*types.TransactionChunk, error) {
-	_path := "chunk/" + strconv.FormatInt(offset, 10)
-	body, statusCode, err := c.httpGet(_path)
-	if err != nil {
-		return nil, fmt.Errorf("httpGet getChunk error: %v", err)
+	if info.dataRoot == nil {
+		defer func() { hook(data, err) }()
+		var chunk *types.TransactionChunk
+		if chunk, err = c.getChunk(offset); err != nil {
+			return
+		}
+		var dataPath []byte
+		if data, dataPath, err = decodeChunk(chunk); err != nil {
+			return
+		}
+		if len(dataPath) == 0 { // nodes may serve the chunk without its proof
+			chunkStart = chunkStartFromOffset(info, offset, len(data))
+			return
+		}
+		chunkStart, err = chunkStartFromPath(dataPath, len(data))
+		return
 	}
 
-	switch statusCode {
-	case 200:
-		txChunk := &types.TransactionChunk{}
-		if err := json.Unmarshal(body, txChunk); err != nil {
-			return nil, err
+	// an invalid chunk is only downloaded again from another gateway of the pool
+	policy := c.RetryPolicy()
+	var verifyErr error
+	invalidNodes := make(map[string]bool)
+	for ; attempt <= policy.MaxAttempts; attempt++ {
+		start = time.Now()
+		chunk, node, err := c.getChunkFrom(offset)
+		if err != nil {
+			hook(nil, err)
+			return nil, 0, err
 		}
-		return txChunk, nil
-	case 404:
-		return nil, ErrNotFound
-	case 429:
-		return nil, ErrRequestLimit
-	default:
-		return nil, ErrBadGateway
+		if invalidNodes[node] { // the pool did not fail over
+			break
+		}
+		var result *utils.ValidateResult
+		data, dataPath, err := decodeChunk(chunk)
+		if err == nil {
+			result, err = utils.ValidateChunk(info.dataRoot, int(info.size), int(offset-info.startOffset), dataPath, data)
+		}
+		hook(data, err)
+		if err == nil {
+			return data, int64(result.LeftBound), nil
+		}
+		verifyErr = err
+		c.log().Warn("invalid chunk", "err", err, "arId", info.id, "offset", offset, "node", node, "attempt", attempt)
+		if c.pool == nil {
+			break
+		}
+		c.pool.report(node, 0, 0, ErrInvalidChunk)
+		invalidNodes[node] = true
 	}
+	return nil, 0, fmt.Errorf("%w: offset: %d, err: %v", ErrInvalidChunk, offset, verifyErr)
 }
 
-func (c *Client) getChunkData(offset int64) ([]byte, error) {
-	chunk, err := c.getChunk(offset)
+// chunkStartFromOffset locates the chunk of chunkSize bytes at the weave offset without its data_path.
+// The chunks are MAX_CHUNK_SIZE aligned, but the last one, that ends with the data.
+func chunkStartFromOffset(info *txDataInfo, offset int64, chunkSize int) int64 {
+	pos := offset - info.startOffset
+	start := pos - pos%types.MAX_CHUNK_SIZE
+	if end := start + int64(chunkSize); end <= pos || end > info.size {
+		start = info.size - int64(chunkSize)
+	}
+	return start
+}
+
+// chunkStartFromPath reads the end offset of the chunk from the note of the leaf of its data_path
+func chunkStartFromPath(dataPath []byte, chunkSize int) (int64, error) {
+	if len(dataPath) < types.HASH_SIZE+types.NOTE_SIZE {
+		return 0, errors.New("data_path too short")
+	}
+	note := new(big.Int).SetBytes(dataPath[len(dataPath)-types.NOTE_SIZE:])
+	return note.Int64() - int64(chunkSize), nil
+}
+
+func decodeChunk(chunk *types.TransactionChunk) (data, dataPath []byte, err error) {
+	data, err = utils.Base64Decode(chunk.Chunk)
 	if err != nil {
-		return nil, err
+		return
 	}
-	return utils.Base64Decode(chunk.Chunk)
+	dataPath, err = utils.Base64Decode(chunk.DataPath)
+	return
 }
 
 func (c *Client) getTransactionOffset(id string) (*types.TransactionOffset, error) {
 	_path := fmt.Sprintf("tx/%s/offset", id)
-	body, statusCode, err := c.httpGet(_path)
-	if statusCode == 429 {
-		return nil, ErrRequestLimit
-	}
-	if statusCode != 200 {
-		return nil, errors.New("not found tx offset")
-	}
-	if err != nil {
-		return nil, err
+	resp := c.get(_path)
+	if !resp.ok(200) {
+		return nil, resp.apiError(ErrNotFound)
 	}
 	txOffset := &types.TransactionOffset{}
-	if err := json.Unmarshal(body, txOffset); err != nil {
+	if err := json.Unmarshal(resp.body, txOffset); err != nil {
 		return nil, err
 	}
 	return txOffset, nil
 }
 
 func (c *Client) DownloadChunkData(id string) ([]byte, error) {
-	offsetResponse, err := c.getTransactionOffset(id)
-	if err != nil {
-		return nil, err
-	}
-	size, err := strconv.ParseInt(offsetResponse.Size, 10, 64)
-	if err != nil {
-		return nil, err
-	}
-	endOffset, err := strconv.ParseInt(offsetResponse.Offset, 10, 64)
+	info, err := c.getTxDataInfo(id)
 	if err != nil {
 		return nil, err
 	}
-	startOffset := endOffset - size + 1
+	size, startOffset, endOffset := info.size, info.startOffset, info.endOffset
+	progress := c.downloadProgress(info)
 	data := make([]byte, 0, size)
-	for i := 0; int64(i)+startOffset < endOffset; {
-		chunkData, err := c.getChunkData(int64(i) + startOffset)
+	for i, idx := 0, 0; int64(i)+startOffset < endOffset; idx++ {
+		if err := c.Context().Err(); err != nil {
+			return nil, err
+		}
+		chunkData, err := c.getTxChunkData(info, int64(i)+startOffset)
 		if err != nil {
+			progress.chunkFailed(idx, 1, err)
 			return nil, err
 		}
 		data = append(data, chunkData...)
-		fmt.Printf("download chunk data; offset: %d/%d; size: %d/%d \n", int64(i)+startOffset, endOffset, len(data), size)
+		progress.chunkDone(idx, len(chunkData))
 		i += len(chunkData)
 	}
 	return data, nil
@@ -639,55 +787,28 @@ func (c *Client) DownloadChunkData(id string) ([]byte, error) {
 // it's caller's responsibility to reserve or delete the tmp file created by this method
 
 func (c *Client) DownloadChunkDataStream(id string) (*os.File, error) {
-	offsetResponse, err := c.getTransactionOffset(id)
+	info, err := c.getTxDataInfo(id)
 	if err != nil {
 		return nil, err
 	}
-	size, err := strconv.ParseInt(offsetResponse.Size, 10, 64)
+	dataFile, err := c.createTemp("chunkData-")
 	if err != nil {
 		return nil, err
 	}
-	endOffset, err := strconv.ParseInt(offsetResponse.Offset, 10, 64)
-	if err != nil {
+	if _, err = c.streamChunks(info, dataFile, 1); err != nil {
+		dataFile.Close()
+		os.Remove(dataFile.Name())
 		return nil, err
 	}
-	startOffset := endOffset - size + 1
-	dataFile, err := os.CreateTemp(".", "chunkData-")
-	if err != nil {
-		return nil, err
-	}
-	downloadSize := 0
-	n := 0
-	for i := 0; int64(i)+startOffset < endOffset; {
-		chunkData, err := c.getChunkData(int64(i) + startOffset)
-		if err != nil {
-			return nil, err
-		}
-		downloadSize += len(chunkData)
-		n, err = dataFile.Write(chunkData)
-		if err != nil || n < len(chunkData) {
-			return nil, fmt.Errorf("write chunkData to dataFile failed")
-		}
-		fmt.Printf("download chunk data; offset: %d/%d; size: %d/%d \n", int64(i)+startOffset, endOffset, downloadSize, size)
-		i += len(chunkData)
-	}
 	return dataFile, nil
 }
 
 func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]byte, error) {
-	offsetResponse, err := c.getTransactionOffset(id)
+	info, err := c.getTxDataInfo(id)
 	if err != nil {
 		return nil, err
 	}
-	size, err := strconv.ParseInt(offsetResponse.Size, 10, 64)
-	if err != nil {
-		return nil, err
-	}
-	endOffset, err := strconv.ParseInt(offsetResponse.Offset, 10, 64)
-	if err != nil {
-		return nil, err
-	}
-	startOffset := endOffset - size + 1
+	size, startOffset, endOffset := info.size, info.startOffset, info.endOffset
 
 	offsetArr := make([]int64, 0, 5)
 	for i := 0; int64(i)+startOffset < endOffset; {
@@ -699,7 +820,7 @@ func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]by
 		return c.DownloadChunkData(id)
 	}
 
-	log.Debug("need download chunks length", "length", len(offsetArr))
+	c.log().Debug("need download chunks length", "length", len(offsetArr))
 	// concurrent get chunks
 	type OffsetSort struct {
 		Idx    int
@@ -707,6 +828,7 @@ func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]by
 	}
 
 	chunkArr := make([][]byte, len(offsetArr)-2)
+	progress := c.downloadProgress(info)
 	var (
 		lock sync.Mutex
 		wg   sync.WaitGroup
@@ -717,24 +839,19 @@ func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]by
 	p, _ := ants.NewPoolWithFunc(concurrentNum, func(i interface{}) {
 		defer wg.Done()
 		oss := i.(OffsetSort)
-		chunkData, err := c.getChunkData(oss.Offset)
+		if c.Context().Err() != nil {
+			return
+		}
+		chunkData, err := c.getTxChunkData(info, oss.Offset)
 		if err != nil {
-			count := 0
-			for count < 2 {
-				time.Sleep(1 * time.Second)
-				chunkData, err = c.getChunkData(oss.Offset)
-				if err == nil {
-					break
-				}
-				log.Error("retry getChunkData failed and try again...", "err", err, "idx", oss.Idx, "offset", oss.Offset, "retryCount", count, "arId", id)
-				if err != ErrRequestLimit {
-					count++
-				}
-			}
+			c.log().Error("getChunkData failed", "err", err, "idx", oss.Idx, "offset", oss.Offset, "arId", id)
+			progress.chunkFailed(oss.Idx, 1, err)
+			return
 		}
 		lock.Lock()
 		chunkArr[oss.Idx] = chunkData
 		lock.Unlock()
+		progress.chunkDone(oss.Idx, len(chunkData))
 	})
 
 	defer p.Release()
@@ -742,33 +859,24 @@ func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]by
 	for i, offset := range offsetArr[:len(offsetArr)-2] {
 		wg.Add(1)
 		if err := p.Invoke(OffsetSort{Idx: i, Offset: offset}); err != nil {
-			log.Error("p.Invoke(i)", "err", err, "i", i)
+			c.log().Error("p.Invoke(i)", "err", err, "i", i)
 			return nil, err
 		}
 	}
 	wg.Wait()
+	if err := c.Context().Err(); err != nil {
+		return nil, err
+	}
 
 	// add latest 2 chunks
 	start := offsetArr[len(offsetArr)-3] + types.MAX_CHUNK_SIZE
 	for i := 0; int64(i)+start < endOffset; {
-		chunkData, err := c.getChunkData(int64(i) + start)
+		chunkData, err := c.getTxChunkData(info, int64(i)+start)
 		if err != nil {
-			count := 0
-			for count < 2 {
-				time.Sleep(1 * time.Second)
-				chunkData, err = c.getChunkData(int64(i) + start)
-				if err == nil {
-					break
-				}
-				log.Error("latest two chunks retry getChunkData failed and try again...", "err", err, "offset", int64(i)+start, "retryCount", count, "arId", id)
-				if err != ErrRequestLimit {
-					count++
-				}
-			}
-		}
-		if err != nil {
-			return nil, errors.New("concurrent get latest two chunks failed")
+			progress.chunkFailed(len(chunkArr), 1, err)
+			return nil, fmt.Errorf("concurrent get latest two chunks failed, err: %v", err)
 		}
+		progress.chunkDone(len(chunkArr), len(chunkData))
 		chunkArr = append(chunkArr, chunkData)
 		i += len(chunkData)
 	}
@@ -787,19 +895,11 @@ func (c *Client) ConcurrentDownloadChunkData(id string, concurrentNum int) ([]by
 // it's caller's responsibility to reserve or delete the tmp file created by this method
 
 func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int) (dataFile *os.File, err error) {
-	offsetResponse, err := c.getTransactionOffset(id)
-	if err != nil {
-		return nil, err
-	}
-	size, err := strconv.ParseInt(offsetResponse.Size, 10, 64)
-	if err != nil {
-		return nil, err
-	}
-	endOffset, err := strconv.ParseInt(offsetResponse.Offset, 10, 64)
+	info, err := c.getTxDataInfo(id)
 	if err != nil {
 		return nil, err
 	}
-	startOffset := endOffset - size + 1
+	startOffset, endOffset := info.startOffset, info.endOffset
 
 	offsetArr := make([]int64, 0, 5)
 	for i := 0; int64(i)+startOffset < endOffset; {
@@ -807,9 +907,9 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 		i += types.MAX_CHUNK_SIZE
 	}
 
-	log.Debug("need download chunks length", "length", len(offsetArr))
+	c.log().Debug("need download chunks length", "length", len(offsetArr))
 
-	dataFile, err = os.CreateTemp(".", "concurrent-load-data-")
+	dataFile, err = c.createTemp("concurrent-load-data-")
 	if err != nil {
 		return nil, err
 	}
@@ -834,9 +934,11 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 		fileOffset  int64
 		chunkOffset int64
 	}
+	progress := c.downloadProgress(info)
 	var (
-		lock sync.Mutex
-		wg   sync.WaitGroup
+		lock   sync.Mutex
+		wg     sync.WaitGroup
+		failed int
 	)
 	if concurrentNum <= 0 {
 		concurrentNum = types.DEFAULT_CHUNK_CONCURRENT_NUM
@@ -844,32 +946,29 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 	p, _ := ants.NewPoolWithFunc(concurrentNum, func(i interface{}) {
 		defer wg.Done()
 		oss := i.(Offset)
-		chunkData, err := c.getChunkData(oss.chunkOffset)
-		if err != nil {
-			count := 0
-			for count < 5 {
-				time.Sleep(1 * time.Second)
-				chunkData, err = c.getChunkData(oss.chunkOffset)
-				if err == nil {
-					break
-				}
-				log.Warn("retry getChunkData failed and try again...", "err", err, "idx", oss.fileOffset/types.MAX_CHUNK_SIZE, "offset", oss.chunkOffset, "retryCount", count, "arId", id)
-				if err != ErrRequestLimit {
-					count++
-				}
-			}
+		if c.Context().Err() != nil {
+			return
 		}
+		chunkData, err := c.getTxChunkData(info, oss.chunkOffset)
 		if err != nil {
-			log.Error("getChunkData failed", "err", err, "arId", id, "idx", oss.fileOffset/types.MAX_CHUNK_SIZE, "offset", oss.chunkOffset)
+			c.log().Error("getChunkData failed", "err", err, "arId", id, "idx", oss.fileOffset/types.MAX_CHUNK_SIZE, "offset", oss.chunkOffset)
+			progress.chunkFailed(int(oss.fileOffset/types.MAX_CHUNK_SIZE), 1, err)
+			lock.Lock()
+			failed++
+			lock.Unlock()
 			return
 		}
 		var n int
 		lock.Lock()
 		n, err = dataFile.WriteAt(chunkData, oss.fileOffset)
 		if err != nil || n < len(chunkData) {
-			log.Error("write dataFile error")
+			c.log().Error("write dataFile error")
+			failed++
 		}
 		lock.Unlock()
+		if err == nil {
+			progress.chunkDone(int(oss.fileOffset/types.MAX_CHUNK_SIZE), n)
+		}
 	})
 
 	defer p.Release()
@@ -877,35 +976,29 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 	for i, offset := range offsetArr[:len(offsetArr)-2] {
 		wg.Add(1)
 		if err = p.Invoke(Offset{fileOffset: offset, chunkOffset: offset + startOffset}); err != nil {
-			log.Error("p.Invoke(i)", "err", err, "i", i)
+			c.log().Error("p.Invoke(i)", "err", err, "i", i)
 			return
 		}
 	}
 	wg.Wait()
+	if err = c.Context().Err(); err != nil {
+		return
+	}
+	if failed > 0 {
+		err = fmt.Errorf("concurrent get chunks failed, failed chunks: %d", failed)
+		return
+	}
 	_, err = dataFile.Seek(0, 2)
 	if err != nil {
 		return
 	}
 	// add latest 2 chunks
 	start := offsetArr[len(offsetArr)-3] + startOffset + types.MAX_CHUNK_SIZE
-	for i := 0; int64(i)+start < endOffset; {
+	for i, idx := 0, len(offsetArr)-2; int64(i)+start < endOffset; idx++ {
 		var chunkData []byte
-		chunkData, err = c.getChunkData(int64(i) + start)
-		if err != nil {
-			count := 0
-			for count < 5 {
-				time.Sleep(1 * time.Second)
-				chunkData, err = c.getChunkData(int64(i) + start)
-				if err == nil {
-					break
-				}
-				log.Error("latest two chunks retry getChunkData failed and try again...", "err", err, "offset", int64(i)+start, "retryCount", count, "arId", id)
-				if err != ErrRequestLimit {
-					count++
-				}
-			}
-		}
+		chunkData, err = c.getTxChunkData(info, int64(i)+start)
 		if err != nil {
+			progress.chunkFailed(idx, 1, err)
 			err = errors.New(fmt.Sprintf("concurrent get latest two chunks failed,err:%v", err))
 			return
 		}
@@ -915,6 +1008,7 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 			err = fmt.Errorf("write dataFile error writeSize:%d, expectSize:%d", n, len(chunkData))
 			return
 		}
+		progress.chunkDone(idx, n)
 		i += len(chunkData)
 	}
 
@@ -923,30 +1017,24 @@ func (c *Client) ConcurrentDownloadChunkDataStream(id string, concurrentNum int)
 
 func (c *Client) GetUnconfirmedTx(arId string) (*types.Transaction, error) {
 	_path := fmt.Sprintf("unconfirmed_tx/%s", arId)
-	body, statusCode, err := c.httpGet(_path)
-	if statusCode != 200 {
-		return nil, errors.New("not found unconfirmed tx")
-	}
-	if err != nil {
-		return nil, err
+	resp := c.get(_path)
+	if !resp.ok(200) {
+		return nil, resp.apiError(ErrNotFound)
 	}
 	tx := &types.Transaction{}
-	if err := json.Unmarshal(body, tx); err != nil {
+	if err := json.Unmarshal(resp.body, tx); err != nil {
 		return nil, err
 	}
 	return tx, nil
 }
 
 func (c *Client) GetPendingTxIds() ([]string, error) {
-	body, statusCode, err := c.httpGet("/tx/pending")
-	if statusCode != 200 {
-		return nil, errors.New("get pending txIds failed")
-	}
-	if err != nil {
-		return nil, err
+	resp := c.get("/tx/pending")
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
 	}
 	res := make([]string, 0)
-	if err := json.Unmarshal(body, &res); err != nil {
+	if err := json.Unmarshal(resp.body, &res); err != nil {
 		return nil, err
 	}
 	return res, nil
@@ -956,16 +1044,13 @@ func (c *Client) GetBlockHashList(from, to int) ([]string, error) {
 	if from > to {
 		return nil, errors.New("from must <= to")
 	}
-	body, statusCode, err := c.httpGet("/hash_list/" + strconv.Itoa(from) + "/" + strconv.Itoa(to))
-	if statusCode != 200 {
-		return nil, errors.New("get block hash list failed")
-	}
-	if err != nil {
-		return nil, err
+	resp := c.get("/hash_list/" + strconv.Itoa(from) + "/" + strconv.Itoa(to))
+	if !resp.ok(200) {
+		return nil, resp.apiError(nil)
 	}
 
 	res := make([]string, 0)
-	if err := json.Unmarshal(body, &res); err != nil {
+	if err := json.Unmarshal(resp.body, &res); err != nil {
 		return nil, err
 	}
 	return res, nil
@@ -976,66 +1061,32 @@ func (c *Client) ExistTxData(arId string) (bool, error) {
 	if err != nil {
 		return false, err
 	}
-	endOffset := offsetResponse.Offset
-
-	records, err := c.DataSyncRecord(endOffset, 1)
-	if err != nil {
-		return false, err
-	}
-	if len(records) == 0 {
-		return false, errors.New("c.DataSyncRecord(endOffset,1) is null")
-	}
-	record := records[0]
-
-	// if tx data has end offset 145 and size 10 (you can see it in GET /tx/<id>/offset),
-	// you can query GET /data_sync_record/145/1
-	// - you will receive {"<end>": "<start>"} => the node has the tx data if start =< 145 - 10
-	mmp := gjson.Parse(record).Map()
-	start := ""
-	for _, val := range mmp {
-		start = val.String()
-		break
-	}
-	startNum, err := strconv.Atoi(start)
-	if err != nil {
-		return false, err
-	}
-	endOffsetNum, err := strconv.Atoi(endOffset)
-	if err != nil {
-		return false, err
-	}
-	sizeNum, err := strconv.Atoi(offsetResponse.Size)
-	if err != nil {
-		return false, err
-	}
-
-	return startNum <= endOffsetNum-sizeNum, nil
+	stored, _, err := c.storesTxData(offsetResponse)
+	return stored, err
 }
 
 // DataSyncRecord you can use GET /data_sync_record/<end_offset>/<number_of_intervals>
 // to fetch the first intervals with end offset >= end_offset;
 // set Content-Type: application/json to get the reply in JSON
 func (c *Client) DataSyncRecord(endOffset string, intervalsNum int) ([]string, error) {
-	req := gentleman.New().URL(c.url).Request()
-	req.AddPath("/data_sync_record/" + endOffset + "/" + strconv.Itoa(intervalsNum))
-	req.SetHeader("Content-Type", "application/json")
-	resp, err := req.Send()
-	if err != nil {
-		return nil, err
-	}
-	if resp.StatusCode == 429 {
-		return nil, ErrRequestLimit
-	}
-	if !resp.Ok {
-		return nil, errors.New("resp ok is false")
+	records, _, err := c.dataSyncRecordFrom(endOffset, intervalsNum)
+	return records, err
+}
+
+// dataSyncRecordFrom also returns the node that served the sync record
+func (c *Client) dataSyncRecordFrom(endOffset string, intervalsNum int) ([]string, string, error) {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	resp := c.do(http.MethodGet, "/data_sync_record/"+endOffset+"/"+strconv.Itoa(intervalsNum), nil, header)
+	if resp.err != nil || resp.statusCode < 200 || resp.statusCode >= 300 {
+		return nil, resp.node, resp.apiError(nil)
 	}
-	defer resp.Close()
-	ss := gjson.ParseBytes(resp.Bytes()).Array()
+	ss := gjson.ParseBytes(resp.body).Array()
 	result := make([]string, 0, len(ss))
 	for _, s := range ss {
 		result = append(result, s.String())
 	}
-	return result, nil
+	return result, resp.node, nil
 }
 
 func (c *Client) SubmitToWarp(tx *types.Transaction) ([]byte, error) {
@@ -1049,7 +1100,7 @@ func (c *Client) SubmitToWarp(tx *types.Transaction) ([]byte, error) {
 	}
 
 	u.Path = path.Join(u.Path, "/gateway/sequencer/register")
-	req, err := http.NewRequest("POST", u.String(), bytes.NewReader(by))
+	req, err := http.NewRequestWithContext(c.Context(), "POST", u.String(), bytes.NewReader(by))
 	if err != nil {
 		return nil, err
 	}
@@ -1075,19 +1126,13 @@ func (c *Client) SubmitToWarp(tx *types.Transaction) ([]byte, error) {
 */
 
 func (c *Client) GetBundleItems(bundleInId string, itemsIds []string) (items []*types.BundleItem, err error) {
-	offset, err := c.getTransactionOffset(bundleInId)
-	if err != nil {
-		return nil, err
-	}
-
-	size, err := strconv.ParseInt(offset.Size, 10, 64)
+	info, err := c.getTxDataInfo(bundleInId)
 	if err != nil {
 		return nil, err
 	}
-	endOffset, err := strconv.ParseInt(offset.Offset, 10, 64)
-	startOffset := endOffset - size + 1
+	startOffset, endOffset := info.startOffset, info.endOffset
 
-	firstChunk, err := c.getChunkData(startOffset)
+	firstChunk, err := c.getTxChunkData(info, startOffset)
 	if err != nil {
 		return nil, err
 	}
@@ -1102,7 +1147,7 @@ func (c *Client) GetBundleItems(bundleInId string, itemsIds []string) (items []*
 		chunkNum := int(math.Ceil(float64(bundleItemStart) / float64(types.MAX_CHUNK_SIZE)))
 
 		for i := 0; i < chunkNum; i++ {
-			chunk, err := c.getChunkData(startOffset + int64(i*types.MAX_CHUNK_SIZE))
+			chunk, err := c.getTxChunkData(info, startOffset+int64(i*types.MAX_CHUNK_SIZE))
 			if err != nil {
 				return nil, err
 			}
@@ -1130,7 +1175,7 @@ func (c *Client) GetBundleItems(bundleInId string, itemsIds []string) (items []*
 				if offset >= endOffset {
 					break
 				}
-				chunk, err := c.getChunkData(offset)
+				chunk, err := c.getTxChunkData(info, offset)
 
 				if err != nil {
 					return nil, err
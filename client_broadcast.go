@@ -1,40 +1,271 @@
 package goar
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
 	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+)
+
+const (
+	DefaultBroadcastTimeout = 5 * time.Minute
+	DefaultProbeTimeout     = 5 * time.Second
 )
 
+// PeerResult is the outcome of the broadcast to one peer
+type PeerResult struct {
+	Peer     string
+	Latency  time.Duration // of its /info, used to rank the peers
+	Duration time.Duration // of the upload, zero if the peer was not seeded
+	Err      error         // the peer was unreachable or the upload failed
+}
+
+// BroadcastReport is the outcome of Broadcast for every peer probed
+type BroadcastReport struct {
+	TxId      string
+	Peers     []PeerResult // the seeded peers in the order they were tried, then the unreachable ones
+	Succeeded int
+}
+
+type BroadcastOption func(o *broadcastOptions)
+
+type broadcastOptions struct {
+	peers            []string
+	nodes            int
+	concurrency      int
+	chunkConcurrency int
+	timeout          time.Duration
+	probeTimeout     time.Duration
+	probeLimit       int
+}
+
+// WithBroadcastPeers seeds these peers instead of the peers of GetPeers
+func WithBroadcastPeers(peers ...string) BroadcastOption {
+	return func(o *broadcastOptions) { o.peers = peers }
+}
+
+// WithBroadcastNodes sets the number of peers to seed, 1 by default
+func WithBroadcastNodes(n int) BroadcastOption {
+	return func(o *broadcastOptions) { o.nodes = n }
+}
+
+// WithBroadcastConcurrency sets the number of peers seeded in parallel, the number of peers to seed by default
+func WithBroadcastConcurrency(n int) BroadcastOption {
+	return func(o *broadcastOptions) { o.concurrency = n }
+}
+
+// WithBroadcastChunkConcurrency sets the number of chunks uploaded in parallel to each peer,
+// types.DEFAULT_CHUNK_CONCURRENT_NUM by default
+func WithBroadcastChunkConcurrency(n int) BroadcastOption {
+	return func(o *broadcastOptions) { o.chunkConcurrency = n }
+}
+
+// WithBroadcastTimeout sets the timeout of the upload to each peer, DefaultBroadcastTimeout by default
+func WithBroadcastTimeout(timeout time.Duration) BroadcastOption {
+	return func(o *broadcastOptions) { o.timeout = timeout }
+}
+
+// WithProbe sets the timeout of the reachability probe of each peer, DefaultProbeTimeout by default, and the number
+// of peers of GetPeers picked at random to be probed at a time, 4 times the number of peers to seed and at least 10
+// by default. The next peers are probed until enough peers are seeded or all the peers were tried.
+func WithProbe(timeout time.Duration, limit int) BroadcastOption {
+	return func(o *broadcastOptions) {
+		o.probeTimeout = timeout
+		o.probeLimit = limit
+	}
+}
+
+// BroadcastData uploads the data of a mined or pending tx to numOfNodes peers, see Broadcast
 func (c *Client) BroadcastData(txId string, data []byte, numOfNodes int64, peers ...string) error {
-	var err error
+	_, err := c.Broadcast(txId, data, WithBroadcastNodes(int(numOfNodes)), WithBroadcastPeers(peers...))
+	return err
+}
+
+// Broadcast uploads the data of a tx, []byte or *os.File, to several peers in parallel, the peers must know the tx.
+// The peers are probed first and seeded from the fastest one, a failed peer is replaced by the next one.
+// The error matches ErrBroadcastFailed when fewer peers than required were seeded, the report tells why.
+func (c *Client) Broadcast(txId string, data interface{}, opts ...BroadcastOption) (*BroadcastReport, error) {
+	o := &broadcastOptions{nodes: 1, timeout: DefaultBroadcastTimeout, probeTimeout: DefaultProbeTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = o.nodes
+	}
+	size, err := uploadDataSize(data)
+	if err != nil {
+		return nil, err
+	}
+	// the chunks are prepared once for all the peers
+	prepared := &types.Transaction{}
+	if err := utils.PrepareChunks(prepared, data, int(size)); err != nil {
+		return nil, err
+	}
+	if o.probeLimit <= 0 {
+		o.probeLimit = 4 * o.nodes
+		if o.probeLimit < 10 {
+			o.probeLimit = 10
+		}
+	}
+	peers, batchSize := o.peers, len(o.peers)
 	if len(peers) == 0 {
-		peers, err = c.GetPeers()
+		all, err := c.GetPeers()
 		if err != nil {
-			return err
+			return nil, err
 		}
+		peers = append([]string(nil), all...)
+		rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+		batchSize = o.probeLimit
 	}
 
-	count := int64(0)
-	pNode := NewTempConn()
-	for _, peer := range peers {
-		pNode.SetTempConnUrl("http://" + peer)
-		uploader, err := CreateUploader(pNode, txId, data)
-		if err != nil {
-			continue
+	report := &BroadcastReport{TxId: txId}
+	progress := newProgressTracker(c.progress, OpBroadcast, txId, size*int64(o.nodes), 0)
+	var (
+		lock        sync.Mutex
+		unreachable []PeerResult
+	)
+	// seed seeds the reachable peers, o.concurrency at a time, until enough peers were seeded
+	seed := func(reachable []PeerResult) {
+		var (
+			next int
+			wg   sync.WaitGroup
+		)
+		// take returns the next peer to seed, until enough peers were seeded
+		take := func() (PeerResult, bool) {
+			lock.Lock()
+			defer lock.Unlock()
+			if report.Succeeded >= o.nodes || next >= len(reachable) || c.Context().Err() != nil {
+				return PeerResult{}, false
+			}
+			next++
+			return reachable[next-1], true
 		}
-
-		if err = uploader.Once(); err != nil {
-			continue
+		for i := 0; i < o.concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					res, ok := take()
+					if !ok {
+						return
+					}
+					start := time.Now()
+					res.Err = c.seedPeer(res.Peer, txId, data, prepared.Chunks, o)
+					res.Duration = time.Since(start)
+					progress.peerDone(res.Peer, int(size), res.Err)
+					if res.Err != nil {
+						c.log().Debug("broadcast to peer failed", "peer", res.Peer, "arId", txId, "err", res.Err)
+					}
+					lock.Lock()
+					report.Peers = append(report.Peers, res)
+					if res.Err == nil {
+						report.Succeeded++
+					}
+					lock.Unlock()
+				}
+			}()
 		}
+		wg.Wait()
+	}
+	// the peers of GetPeers are probed batchSize at a time, until enough peers were seeded
+	for len(peers) > 0 && report.Succeeded < o.nodes && c.Context().Err() == nil {
+		batch := peers
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		peers = peers[len(batch):]
+		reachable, failed := c.probePeers(batch, o.probeTimeout)
+		unreachable = append(unreachable, failed...)
+		seed(reachable)
+	}
+	report.Peers = append(report.Peers, unreachable...)
 
-		count++
-		if count >= numOfNodes {
-			return nil
+	if report.Succeeded < o.nodes {
+		return report, fmt.Errorf("%w: txId: %s, %d/%d peers seeded, %d unreachable",
+			ErrBroadcastFailed, txId, report.Succeeded, o.nodes, len(unreachable))
+	}
+	return report, nil
+}
+
+// seedPeer uploads the data of the tx to the peer, within the broadcast timeout.
+// The peer must know the tx, whose data_root must match the chunks prepared from the data.
+func (c *Client) seedPeer(peer, txId string, data interface{}, chunks *types.Chunks, o *broadcastOptions) error {
+	ctx, cancel := context.WithTimeout(c.Context(), o.timeout)
+	defer cancel()
+	pNode := c.peerClient(peer, 0).WithContext(ctx)
+	tx, err := pNode.GetTransactionByID(txId)
+	if err != nil {
+		return fmt.Errorf("Tx %s not found; error: %w", txId, err)
+	}
+	if tx.DataRoot != utils.Base64Encode(chunks.DataRoot) {
+		return errors.New("Data mismatch: Uploader doesn't match provided Data.")
+	}
+	tx.Data = ""
+	tx.Chunks = chunks
+	uploader, err := newUploader(tx, pNode)
+	if err != nil {
+		return err
+	}
+	uploader.TxPosted = true
+	switch d := data.(type) {
+	case []byte:
+		uploader.Data = d
+	case *os.File:
+		uploader.DataReader = d
+	}
+	return uploader.ConcurrentOnce(ctx, o.chunkConcurrency)
+}
+
+// uploadDataSize returns the size of the data of an upload, []byte or *os.File
+func uploadDataSize(data interface{}) (int64, error) {
+	switch d := data.(type) {
+	case []byte:
+		return int64(len(d)), nil
+	case *os.File:
+		info, err := d.Stat()
+		if err != nil {
+			return 0, err
 		}
+		return info.Size(), nil
 	}
+	return 0, fmt.Errorf("unsupported data type %T", data)
+}
 
-	return fmt.Errorf("upload tx data to peers failed, txId: %s", txId)
+// probePeers measures the latency of the /info of the peers in parallel, the reachable peers are sorted by latency
+func (c *Client) probePeers(peers []string, timeout time.Duration) (reachable, unreachable []PeerResult) {
+	results := make([]PeerResult, len(peers))
+	var wg sync.WaitGroup
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer string) {
+			defer wg.Done()
+			pNode := c.peerClient(peer, timeout)
+			pNode.SetRetryPolicy(NoRetry) // a retried probe would not measure the latency
+			start := time.Now()
+			_, err := pNode.GetInfo()
+			results[i] = PeerResult{Peer: peer, Latency: time.Since(start), Err: err}
+		}(i, peer)
+	}
+	wg.Wait()
+
+	for _, res := range results {
+		if res.Err != nil {
+			unreachable = append(unreachable, res)
+		} else {
+			reachable = append(reachable, res)
+		}
+	}
+	sort.SliceStable(reachable, func(i, j int) bool {
+		return reachable[i].Latency < reachable[j].Latency
+	})
+	return
 }
 
 func (c *Client) GetTxDataFromPeers(txId string, peers ...string) ([]byte, error) {
@@ -75,7 +306,7 @@ func (c *Client) GetBlockFromPeers(height int64, peers ...string) (*types.Block,
 		if err != nil {
 			continue
 		}
-		fmt.Printf("success get block; peer: %s\n", peer)
+		c.log().Debug("success get block", "peer", peer, "height", height)
 		return block, nil
 	}
 
@@ -98,7 +329,7 @@ func (c *Client) GetTxFromPeers(arId string, peers ...string) (*types.Transactio
 		if err != nil {
 			continue
 		}
-		fmt.Printf("success get tx; peer: %s, arTx: %s\n", peer, arId)
+		c.log().Debug("success get tx", "peer", peer, "arId", arId)
 		return tx, nil
 	}
 
@@ -121,7 +352,7 @@ func (c *Client) GetUnconfirmedTxFromPeers(arId string, peers ...string) (*types
 		if err != nil {
 			continue
 		}
-		fmt.Printf("success get unconfirmed tx; peer: %s, arTx: %s\n", peer, arId)
+		c.log().Debug("success get unconfirmed tx", "peer", peer, "arId", arId)
 		return tx, nil
 	}
 
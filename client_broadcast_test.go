@@ -0,0 +1,193 @@
+package goar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPeer accepts the data of tx, unless it does not know the tx or is slower than the broadcast timeout
+type mockPeer struct {
+	tx      *types.Transaction
+	unknown bool
+	delay   time.Duration
+	chunks  int32
+}
+
+func (p *mockPeer) serve() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/info":
+			w.Write([]byte(`{"network":"arweave.N.1"}`))
+		case "/tx/" + p.tx.ID:
+			if p.unknown {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			json.NewEncoder(w).Encode(p.tx)
+		case "/chunk":
+			time.Sleep(p.delay)
+			atomic.AddInt32(&p.chunks, 1)
+			w.Write([]byte("OK"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestClient_Broadcast(t *testing.T) {
+	data := make([]byte, 3*types.MAX_CHUNK_SIZE)
+	rand.Read(data)
+	tx := &types.Transaction{Format: 2, ID: "mock-tx", DataSize: strconv.Itoa(len(data))}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+
+	peers := map[string]*mockPeer{
+		"ok1":     {tx: tx},
+		"ok2":     {tx: tx},
+		"unknown": {tx: tx, unknown: true},
+		"slow":    {tx: tx, delay: 3 * time.Second},
+	}
+	names := make(map[string]string)
+	addrs := make([]string, 0, len(peers)+1)
+	for name, p := range peers {
+		srv := p.serve()
+		defer srv.Close()
+		addr := strings.TrimPrefix(srv.URL, "http://")
+		names[addr] = name
+		addrs = append(addrs, addr)
+	}
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	addr := strings.TrimPrefix(down.URL, "http://")
+	names[addr] = "down"
+	addrs = append(addrs, addr)
+
+	c := NewClient("http://127.0.0.1:1")
+	report, err := c.Broadcast(tx.ID, data,
+		WithBroadcastPeers(addrs...),
+		WithBroadcastNodes(2),
+		WithBroadcastConcurrency(4),
+		WithBroadcastTimeout(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, len(addrs), len(report.Peers))
+	for _, res := range report.Peers {
+		switch names[res.Peer] {
+		case "ok1", "ok2":
+			assert.NoError(t, res.Err)
+			assert.True(t, res.Duration > 0)
+		case "unknown":
+			assert.ErrorContains(t, res.Err, "not found")
+		case "slow":
+			assert.ErrorIs(t, res.Err, context.DeadlineExceeded)
+		case "down":
+			assert.Error(t, res.Err)
+			assert.Equal(t, time.Duration(0), res.Duration)
+		}
+	}
+	assert.Equal(t, int32(3), atomic.LoadInt32(&peers["ok1"].chunks))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&peers["ok2"].chunks))
+
+	// only two peers can be seeded
+	report, err = c.Broadcast(tx.ID, data,
+		WithBroadcastPeers(addrs...),
+		WithBroadcastNodes(3),
+		WithBroadcastTimeout(time.Second))
+	assert.ErrorIs(t, err, ErrBroadcastFailed)
+	assert.Equal(t, 2, report.Succeeded)
+
+	// the data of a file is read concurrently by the peers
+	f, err := os.CreateTemp(t.TempDir(), "data")
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = f.Write(data)
+	assert.NoError(t, err)
+	report, err = c.Broadcast(tx.ID, f,
+		WithBroadcastPeers(addrs...),
+		WithBroadcastNodes(2),
+		WithBroadcastConcurrency(4),
+		WithBroadcastTimeout(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, report.Succeeded)
+	assert.Equal(t, int32(9), atomic.LoadInt32(&peers["ok1"].chunks))
+	assert.Equal(t, int32(9), atomic.LoadInt32(&peers["ok2"].chunks))
+	_, err = c.Broadcast(tx.ID, "data", WithBroadcastPeers(addrs...))
+	assert.Error(t, err)
+
+	// the data does not match the data_root of the tx
+	report, err = c.Broadcast(tx.ID, data[1:], WithBroadcastPeers(addrs...), WithBroadcastTimeout(time.Second))
+	assert.ErrorIs(t, err, ErrBroadcastFailed)
+	for _, res := range report.Peers {
+		if name := names[res.Peer]; name == "ok1" || name == "ok2" {
+			assert.ErrorContains(t, res.Err, "Data mismatch")
+		}
+	}
+
+	var unknown []string
+	for addr, name := range names {
+		if name == "unknown" || name == "down" {
+			unknown = append(unknown, addr)
+		}
+	}
+	assert.ErrorIs(t, c.BroadcastData(tx.ID, data, 1, unknown...), ErrBroadcastFailed)
+}
+
+func TestClient_Broadcast_GetPeers(t *testing.T) {
+	data := make([]byte, types.MAX_CHUNK_SIZE)
+	rand.Read(data)
+	tx := &types.Transaction{Format: 2, ID: "mock-tx", DataSize: strconv.Itoa(len(data))}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+
+	// a single peer out of 5 can be seeded, the others do not know the tx
+	ok := &mockPeer{tx: tx}
+	peers := make([]string, 0, 5)
+	for i := 0; i < 5; i++ {
+		p := &mockPeer{tx: tx, unknown: true}
+		if i == 3 {
+			p = ok
+		}
+		srv := p.serve()
+		defer srv.Close()
+		// GetPeers filters 127.0.0.x out
+		peers = append(peers, strings.Replace(srv.URL, "http://127.0.0.1", "localhost", 1))
+	}
+	node := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/peers", r.URL.Path)
+		json.NewEncoder(w).Encode(peers)
+	}))
+	defer node.Close()
+	c := NewClient(node.URL)
+	// the peers are requested with the hooks of the client
+	var posted int32
+	c.SetHooks(Hooks{RequestFinish: func(ctx context.Context, req RequestInfo, res RequestResult) {
+		if req.Method == http.MethodPost && req.Endpoint == "/chunk" && res.StatusCode == http.StatusOK {
+			atomic.AddInt32(&posted, 1)
+		}
+	}})
+
+	// the peers are probed 2 at a time until one is seeded
+	report, err := c.Broadcast(tx.ID, data, WithProbe(time.Second, 2), WithBroadcastTimeout(time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&ok.chunks))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&posted))
+	assert.LessOrEqual(t, len(report.Peers), 5)
+
+	// all the peers are tried
+	report, err = c.Broadcast(tx.ID, data, WithBroadcastNodes(2), WithProbe(time.Second, 2), WithBroadcastTimeout(time.Second))
+	assert.ErrorIs(t, err, ErrBroadcastFailed)
+	assert.Equal(t, 1, report.Succeeded)
+	assert.Equal(t, 5, len(report.Peers))
+}
@@ -0,0 +1,208 @@
+package goar
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// response of a gateway request, after retries and failover
+type response struct {
+	id         string // request id, shared by the attempts
+	method     string
+	node       string // node of the last attempt
+	url        string // url of the last attempt
+	statusCode int    // 0 if no response was received
+	header     http.Header
+	body       []byte
+	err        error // transport error
+	attempts   int
+}
+
+// ok reports whether the request got a response with the given status code
+func (r *response) ok(statusCode int) bool {
+	return r.err == nil && r.statusCode == statusCode
+}
+
+// apiError converts the response to an *APIError.
+// fallback is the sentinel used when the status code does not map to one of defaultSentinel.
+func (r *response) apiError(fallback error) *APIError {
+	sentinel := defaultSentinel(r.statusCode, r.err)
+	if sentinel == nil {
+		sentinel = fallback
+	}
+	return &APIError{
+		Method:     r.method,
+		Endpoint:   r.url,
+		StatusCode: r.statusCode,
+		Code:       gatewayErrorCode(r.body),
+		Body:       string(r.body),
+		RetryAfter: parseRetryAfter(r.header),
+		Err:        r.err,
+		sentinel:   sentinel,
+	}
+}
+
+func (c *Client) httpGet(_path string) (body []byte, statusCode int, err error) {
+	resp := c.get(_path)
+	return resp.body, resp.statusCode, resp.err
+}
+
+func (c *Client) httpPost(_path string, payload []byte) (body []byte, statusCode int, err error) {
+	resp := c.post(_path, payload)
+	return resp.body, resp.statusCode, resp.err
+}
+
+func (c *Client) get(_path string) *response {
+	return c.do(http.MethodGet, _path, nil, nil)
+}
+
+func (c *Client) post(_path string, payload []byte) *response {
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	return c.do(http.MethodPost, _path, payload, header)
+}
+
+// do sends the request and retries it according to the client's retry policy
+func (c *Client) do(method, _path string, payload []byte, header http.Header) *response {
+	policy := c.RetryPolicy()
+	reqId := nextRequestId()
+	start := time.Now()
+	for attempt := 1; ; attempt++ {
+		resp := c.send(reqId, attempt, method, _path, payload, header)
+		resp.id = reqId
+		resp.attempts = attempt
+		if attempt >= policy.MaxAttempts || !policy.ShouldRetry(resp.statusCode, resp.body, resp.err) {
+			c.log().Debug("request", "reqId", reqId, "method", method, "url", resp.url, "statusCode", resp.statusCode,
+				"err", resp.err, "attempts", attempt, "duration", time.Since(start))
+			return resp
+		}
+		delay := policy.Backoff(attempt, parseRetryAfter(resp.header))
+		c.log().Debug("retry request", "reqId", reqId, "path", _path, "attempt", attempt, "statusCode", resp.statusCode, "err", resp.err, "delay", delay)
+		if err := c.sleep(delay); err != nil {
+			return resp
+		}
+	}
+}
+
+var requestSeq uint64
+
+// nextRequestId returns the id identifying a request and its retries in the logs
+func nextRequestId() string {
+	return strconv.FormatUint(atomic.AddUint64(&requestSeq, 1), 10)
+}
+
+// send performs a single attempt, failing over between the gateways of a pooled client
+func (c *Client) send(reqId string, attempt int, method, _path string, payload []byte, header http.Header) *response {
+	if c.pool == nil {
+		return c.request(reqId, attempt, c.url, method, _path, payload, header)
+	}
+
+	var resp *response
+	for _, nodeUrl := range c.pool.candidates() {
+		start := time.Now()
+		resp = c.request(reqId, attempt, nodeUrl, method, _path, payload, header)
+		c.pool.report(nodeUrl, time.Since(start), resp.statusCode, resp.err)
+		if !shouldFailover(method, resp.statusCode, resp.err) {
+			return resp
+		}
+		c.log().Debug("gateway failover", "reqId", reqId, "url", nodeUrl, "path", _path, "statusCode", resp.statusCode, "err", resp.err)
+	}
+	return resp
+}
+
+func (c *Client) request(reqId string, attempt int, nodeUrl, method, _path string, payload []byte, header http.Header) *response {
+	resp := &response{method: method, node: nodeUrl, url: nodeUrl + "/" + _path}
+	u, err := url.Parse(nodeUrl)
+	if err != nil {
+		resp.err = err
+		return resp
+	}
+
+	u.Path = path.Join(u.Path, _path)
+	resp.url = u.String()
+
+	class := endpointClass(method, _path)
+	ctx := c.Context()
+	if c.hooks.RequestStart != nil || c.hooks.RequestFinish != nil {
+		info := RequestInfo{
+			RequestId: reqId,
+			Method:    method,
+			Endpoint:  endpointName(_path),
+			Class:     class,
+			Url:       resp.url,
+			Attempt:   attempt,
+			BytesSent: len(payload),
+		}
+		if c.hooks.RequestStart != nil {
+			ctx = c.hooks.RequestStart(ctx, info)
+		}
+		if c.hooks.RequestFinish != nil {
+			start := time.Now()
+			defer func() {
+				c.hooks.RequestFinish(ctx, info, RequestResult{
+					StatusCode:    resp.statusCode,
+					BytesReceived: len(resp.body),
+					Duration:      time.Since(start),
+					Err:           resp.err,
+				})
+			}()
+		}
+	}
+
+	if c.limiter != nil {
+		if err := c.limiter.Wait(ctx, class); err != nil {
+			resp.err = err
+			return resp
+		}
+	}
+
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), reqBody)
+	if err != nil {
+		resp.err = err
+		return resp
+	}
+	// copied, the header of the client is shared by the concurrent requests and a custom transport may modify them
+	for k, vs := range c.header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+	for k, vs := range header {
+		req.Header[k] = append([]string(nil), vs...)
+	}
+
+	httpResp, err := c.client.Do(req)
+	if err != nil {
+		resp.err = err
+		return resp
+	}
+	defer httpResp.Body.Close()
+	if c.limiter != nil {
+		c.limiter.OnResponse(class, httpResp.StatusCode)
+	}
+
+	resp.statusCode = httpResp.StatusCode
+	resp.header = httpResp.Header
+	resp.body, resp.err = io.ReadAll(httpResp.Body)
+	return resp
+}
+
+// sleep waits for d or until the client's context is done
+func (c *Client) sleep(d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-c.Context().Done():
+		return c.Context().Err()
+	case <-t.C:
+		return nil
+	}
+}
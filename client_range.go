@@ -0,0 +1,104 @@
+package goar
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/everFinance/goar/types"
+	"github.com/panjf2000/ants/v2"
+)
+
+// GetTransactionDataRange returns the bytes [offset, offset+length) of the data of the tx.
+// Only the chunks covering the range are downloaded, concurrently and verified like DownloadChunkData.
+// A range running past the end of the data is truncated to it.
+func (c *Client) GetTransactionDataRange(id string, offset, length int64) ([]byte, error) {
+	if offset < 0 || length < 0 {
+		return nil, fmt.Errorf("invalid range, offset: %d, length: %d", offset, length)
+	}
+	info, err := c.getTxDataInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	if offset >= info.size {
+		return nil, fmt.Errorf("offset %d out of data size %d", offset, info.size)
+	}
+	end := offset + length
+	if end > info.size {
+		end = info.size
+	}
+	if end == offset {
+		return []byte{}, nil
+	}
+
+	type chunk struct {
+		start int64 // in the tx data
+		data  []byte
+	}
+	var (
+		lock   sync.Mutex
+		wg     sync.WaitGroup
+		chunks []chunk
+	)
+	p, err := ants.NewPoolWithFunc(types.DEFAULT_CHUNK_CONCURRENT_NUM, func(i interface{}) {
+		defer wg.Done()
+		pos := i.(int64)
+		if c.Context().Err() != nil {
+			return
+		}
+		data, start, err := c.getTxChunk(info, info.startOffset+pos)
+		if err != nil {
+			c.log().Error("getChunkData failed", "err", err, "arId", id, "offset", info.startOffset+pos)
+			return
+		}
+		lock.Lock()
+		chunks = append(chunks, chunk{start: start, data: data})
+		lock.Unlock()
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer p.Release()
+
+	// every chunk but the last two is MAX_CHUNK_SIZE and aligned, the rebalanced last two
+	// may leave gaps that are filled in one by one below
+	for pos := offset / types.MAX_CHUNK_SIZE * types.MAX_CHUNK_SIZE; pos < end; pos += types.MAX_CHUNK_SIZE {
+		wg.Add(1)
+		if err := p.Invoke(pos); err != nil {
+			wg.Done()
+			return nil, err
+		}
+	}
+	wg.Wait()
+
+	data := make([]byte, 0, end-offset)
+	for cursor := offset; cursor < end; {
+		if err := c.Context().Err(); err != nil {
+			return nil, err
+		}
+		var cur *chunk
+		for i := range chunks {
+			if chunks[i].start <= cursor && cursor < chunks[i].start+int64(len(chunks[i].data)) {
+				cur = &chunks[i]
+				break
+			}
+		}
+		if cur == nil {
+			chunkData, start, err := c.getTxChunk(info, info.startOffset+cursor)
+			if err != nil {
+				return nil, err
+			}
+			if start > cursor || cursor >= start+int64(len(chunkData)) {
+				return nil, fmt.Errorf("chunk at offset %d does not cover it", info.startOffset+cursor)
+			}
+			chunks = append(chunks, chunk{start: start, data: chunkData})
+			continue
+		}
+		to := cur.start + int64(len(cur.data))
+		if to > end {
+			to = end
+		}
+		data = append(data, cur.data[cursor-cur.start:to-cur.start]...)
+		cursor = to
+	}
+	return data, nil
+}
@@ -0,0 +1,118 @@
+package goar
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetTransactionDataRange(t *testing.T) {
+	id := "mock-tx"
+	// the last two chunks are rebalanced to (MAX_CHUNK_SIZE+100)/2 bytes each
+	data := make([]byte, 5*types.MAX_CHUNK_SIZE+100)
+	for i := range data {
+		data[i] = byte(i * 31)
+	}
+	var (
+		lock    sync.Mutex
+		fetched = map[int]int{}
+	)
+	srv := mockChunkNode(t, id, data, func(idx int, chunk []byte) []byte {
+		lock.Lock()
+		fetched[idx]++
+		lock.Unlock()
+		return chunk
+	})
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	size := int64(len(data))
+	tail := int64(4*types.MAX_CHUNK_SIZE + (types.MAX_CHUNK_SIZE+100)/2)
+	ranges := []struct{ offset, length int64 }{
+		{0, size},
+		{10, 100},
+		{types.MAX_CHUNK_SIZE - 10, 20},
+		{2*types.MAX_CHUNK_SIZE + 5, types.MAX_CHUNK_SIZE},
+		{tail - 10, 20},
+		{tail + 10, 20},
+		{size - 5, 100}, // truncated
+	}
+	for _, verify := range []bool{true, false} {
+		c.SetChunkVerification(verify)
+		for _, r := range ranges {
+			got, err := c.GetTransactionDataRange(id, r.offset, r.length)
+			assert.NoError(t, err)
+			end := r.offset + r.length
+			if end > size {
+				end = size
+			}
+			assert.Equal(t, data[r.offset:end], got, "offset: %d, length: %d", r.offset, r.length)
+		}
+	}
+	c.SetChunkVerification(true)
+
+	// only the covering chunks are downloaded
+	fetched = map[int]int{}
+	_, err := c.GetTransactionDataRange(id, types.MAX_CHUNK_SIZE+1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]int{1: 1}, fetched)
+
+	_, err = c.GetTransactionDataRange(id, size, 1)
+	assert.Error(t, err)
+	_, err = c.GetTransactionDataRange(id, -1, 1)
+	assert.Error(t, err)
+}
+
+func TestClient_GetTransactionDataRange_NoDataPath(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 3*types.MAX_CHUNK_SIZE+100)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	srv := mockChunkNode(t, id, data, nil)
+	defer srv.Close()
+	// the node serves the chunks without their data_path
+	noPath := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp, err := http.Get(srv.URL + r.URL.Path)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(r.URL.Path, "/chunk/") {
+			w.WriteHeader(resp.StatusCode)
+			w.Write(body)
+			return
+		}
+		chunk := map[string]string{}
+		assert.NoError(t, json.Unmarshal(body, &chunk))
+		delete(chunk, "data_path")
+		assert.NoError(t, json.NewEncoder(w).Encode(chunk))
+	}))
+	defer noPath.Close()
+
+	c := NewClient(noPath.URL)
+	c.SetChunkVerification(false)
+	got, err := c.GetTransactionDataRange(id, 0, int64(len(data)))
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+	// in the rebalanced last two chunks
+	got, err = c.GetTransactionDataRange(id, 2*types.MAX_CHUNK_SIZE+10, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, data[2*types.MAX_CHUNK_SIZE+10:2*types.MAX_CHUNK_SIZE+110], got)
+	got, err = c.DownloadChunkData(id)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// the chunks can not be verified
+	c.SetChunkVerification(true)
+	_, err = c.GetTransactionDataRange(id, 0, 10)
+	assert.Error(t, err)
+}
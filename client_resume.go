@@ -0,0 +1,243 @@
+package goar
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/panjf2000/ants/v2"
+)
+
+// DownloadCheckpointSuffix is appended to the destination of a resumable download to name its checkpoint file
+const DownloadCheckpointSuffix = ".goar-checkpoint"
+
+// the checkpoint is a header line followed by one line per chunk written to the destination
+type checkpointHeader struct {
+	Id   string `json:"id"`
+	Size int64  `json:"size"`
+}
+
+type checkpointChunk struct {
+	Start int64  `json:"start"` // in the tx data
+	Size  int    `json:"size"`
+	Hash  string `json:"hash"` // sha256 of the chunk
+}
+
+// DownloadChunkDataResumable downloads the data of the tx to the file dest.
+// Every chunk written is recorded in the checkpoint file dest+DownloadCheckpointSuffix. If the download fails,
+// calling it again checks the recorded chunks against dest and downloads only the missing ones.
+// The checkpoint is removed once the download is complete.
+func (c *Client) DownloadChunkDataResumable(id, dest string, concurrentNum int) error {
+	info, err := c.getTxDataInfo(id)
+	if err != nil {
+		return err
+	}
+
+	dataFile, err := os.OpenFile(dest, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer dataFile.Close()
+
+	ckptPath := dest + DownloadCheckpointSuffix
+	done, err := c.loadCheckpoint(ckptPath, info, dataFile)
+	if err != nil {
+		return err
+	}
+	if len(done) == 0 {
+		// nothing to resume, dest is overwritten
+		if err = dataFile.Truncate(0); err != nil {
+			return err
+		}
+	}
+	ckpt, err := writeCheckpoint(ckptPath, info, done)
+	if err != nil {
+		return err
+	}
+	defer ckpt.Close()
+	if len(done) > 0 {
+		c.log().Info("resume download", "arId", id, "doneChunks", len(done))
+	}
+
+	progress := c.downloadProgress(info)
+	resumed := int64(0)
+	for _, chunk := range done {
+		resumed += int64(chunk.Size)
+	}
+	progress.resumeAt(resumed)
+
+	var lock sync.Mutex
+	save := func(data []byte, start int64) error {
+		if _, err := dataFile.WriteAt(data, start); err != nil {
+			return err
+		}
+		hash := sha256.Sum256(data)
+		chunk := checkpointChunk{Start: start, Size: len(data), Hash: utils.Base64Encode(hash[:])}
+		line, _ := json.Marshal(chunk)
+		lock.Lock()
+		defer lock.Unlock()
+		done[start] = chunk
+		if _, err := ckpt.Write(append(line, '\n')); err != nil {
+			return err
+		}
+		progress.chunkDone(int(start/types.MAX_CHUNK_SIZE), len(data))
+		return nil
+	}
+
+	// the MAX_CHUNK_SIZE aligned chunks are downloaded concurrently, the rebalanced last two one by one below
+	if concurrentNum <= 0 {
+		concurrentNum = types.DEFAULT_CHUNK_CONCURRENT_NUM
+	}
+	var (
+		wg       sync.WaitGroup
+		failed   int
+		firstErr error
+	)
+	p, err := ants.NewPoolWithFunc(concurrentNum, func(i interface{}) {
+		defer wg.Done()
+		pos := i.(int64)
+		if c.Context().Err() != nil {
+			return
+		}
+		data, start, err := c.getTxChunk(info, info.startOffset+pos)
+		if err == nil {
+			err = save(data, start)
+		}
+		if err != nil {
+			c.log().Error("download chunk failed", "err", err, "arId", id, "offset", info.startOffset+pos)
+			progress.chunkFailed(int(pos/types.MAX_CHUNK_SIZE), 1, err)
+			lock.Lock()
+			if failed++; firstErr == nil {
+				firstErr = err
+			}
+			lock.Unlock()
+		}
+	})
+	if err != nil {
+		return err
+	}
+	defer p.Release()
+
+	todo := make([]int64, 0)
+	for pos := int64(0); pos+2*types.MAX_CHUNK_SIZE < info.size; pos += types.MAX_CHUNK_SIZE {
+		if _, ok := done[pos]; !ok {
+			todo = append(todo, pos)
+		}
+	}
+	c.log().Debug("need download chunks length", "length", len(todo))
+	for _, pos := range todo {
+		wg.Add(1)
+		if err = p.Invoke(pos); err != nil {
+			wg.Done()
+			return err
+		}
+	}
+	wg.Wait()
+	if err = c.Context().Err(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("download chunks failed, failed chunks: %d, call again to resume: %w", failed, firstErr)
+	}
+
+	for cursor := int64(0); cursor < info.size; {
+		if chunk, ok := done[cursor]; ok {
+			cursor += int64(chunk.Size)
+			continue
+		}
+		if err = c.Context().Err(); err != nil {
+			return err
+		}
+		data, start, err := c.getTxChunk(info, info.startOffset+cursor)
+		if err != nil {
+			progress.chunkFailed(int(cursor/types.MAX_CHUNK_SIZE), 1, err)
+			return err
+		}
+		if start != cursor {
+			return fmt.Errorf("chunk at offset %d does not start at %d", info.startOffset+cursor, cursor)
+		}
+		if err = save(data, start); err != nil {
+			return err
+		}
+	}
+
+	if err = dataFile.Truncate(info.size); err != nil {
+		return err
+	}
+	if err = dataFile.Sync(); err != nil {
+		return err
+	}
+	ckpt.Close()
+	return os.Remove(ckptPath)
+}
+
+// loadCheckpoint returns the chunks recorded in the checkpoint that are intact in dataFile, by start offset
+func (c *Client) loadCheckpoint(path string, info *txDataInfo, dataFile *os.File) (map[int64]checkpointChunk, error) {
+	done := make(map[int64]checkpointChunk)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	header := checkpointHeader{}
+	if !scanner.Scan() || json.Unmarshal(scanner.Bytes(), &header) != nil ||
+		header.Id != info.id || header.Size != info.size {
+		c.log().Warn("checkpoint does not match the tx, start over", "path", path, "arId", info.id)
+		return done, nil
+	}
+
+	buf := make([]byte, types.MAX_CHUNK_SIZE)
+	for scanner.Scan() {
+		chunk := checkpointChunk{}
+		if err := json.Unmarshal(scanner.Bytes(), &chunk); err != nil {
+			break // interrupted while writing the last line
+		}
+		if chunk.Start < 0 || chunk.Size <= 0 || chunk.Size > types.MAX_CHUNK_SIZE || chunk.Start+int64(chunk.Size) > info.size {
+			continue
+		}
+		if n, err := dataFile.ReadAt(buf[:chunk.Size], chunk.Start); n < chunk.Size {
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			continue
+		}
+		hash := sha256.Sum256(buf[:chunk.Size])
+		if utils.Base64Encode(hash[:]) != chunk.Hash {
+			continue // not flushed before the interruption
+		}
+		done[chunk.Start] = chunk
+	}
+	return done, nil
+}
+
+// writeCheckpoint rewrites the checkpoint with the chunks done and returns it open for appending
+func writeCheckpoint(path string, info *txDataInfo, done map[int64]checkpointChunk) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return nil, err
+	}
+	w := bufio.NewWriter(f)
+	line, _ := json.Marshal(checkpointHeader{Id: info.id, Size: info.size})
+	w.Write(append(line, '\n'))
+	for _, chunk := range done {
+		line, _ = json.Marshal(chunk)
+		w.Write(append(line, '\n'))
+	}
+	if err = w.Flush(); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,59 @@
+package goar
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_DownloadChunkDataResumable(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 8*types.MAX_CHUNK_SIZE+100)
+	for i := range data {
+		data[i] = byte(i * 17)
+	}
+	var (
+		lock    sync.Mutex
+		broken  = true
+		fetched = map[int]int{}
+	)
+	srv := mockChunkNode(t, id, data, func(idx int, chunk []byte) []byte {
+		lock.Lock()
+		defer lock.Unlock()
+		fetched[idx]++
+		if broken && idx == 3 {
+			chunk[0]++
+		}
+		return chunk
+	})
+	defer srv.Close()
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+
+	dest := filepath.Join(t.TempDir(), "data")
+	err := c.DownloadChunkDataResumable(id, dest, 4)
+	assert.ErrorIs(t, err, ErrInvalidChunk)
+	assert.FileExists(t, dest+DownloadCheckpointSuffix)
+
+	// a chunk recorded in the checkpoint is damaged on disk, it is downloaded again
+	f, err := os.OpenFile(dest, os.O_RDWR, 0)
+	assert.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff ^ data[types.MAX_CHUNK_SIZE]}, types.MAX_CHUNK_SIZE)
+	assert.NoError(t, err)
+	f.Close()
+
+	broken = false
+	fetched = map[int]int{}
+	err = c.DownloadChunkDataResumable(id, dest, 4)
+	assert.NoError(t, err)
+	// the last two chunks were not reached by the first attempt
+	assert.Equal(t, map[int]int{1: 1, 3: 1, 7: 1, 8: 1}, fetched)
+	got, err := os.ReadFile(dest)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.NoFileExists(t, dest+DownloadCheckpointSuffix)
+}
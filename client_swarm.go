@@ -0,0 +1,235 @@
+package goar
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/panjf2000/ants/v2"
+)
+
+const (
+	DefaultSwarmPeers       = 8
+	DefaultSwarmConcurrency = 2
+)
+
+// a source failing this many chunks is not used anymore
+const maxSwarmSourceFailures = 3
+
+type SwarmOption func(o *swarmOptions)
+
+type swarmOptions struct {
+	peers       []string
+	size        int
+	concurrency int
+	timeout     time.Duration
+}
+
+// WithSwarmPeers downloads from these peers instead of a sample of GetPeers
+func WithSwarmPeers(peers ...string) SwarmOption {
+	return func(o *swarmOptions) { o.peers = peers }
+}
+
+// WithSwarmSize sets the number of peers picked at random from GetPeers, DefaultSwarmPeers by default
+func WithSwarmSize(n int) SwarmOption {
+	return func(o *swarmOptions) { o.size = n }
+}
+
+// WithSwarmConcurrency sets the number of chunks downloaded in parallel from each source, DefaultSwarmConcurrency by default
+func WithSwarmConcurrency(n int) SwarmOption {
+	return func(o *swarmOptions) { o.concurrency = n }
+}
+
+// WithSwarmTimeout sets the timeout of the requests to each peer, DefaultPeerTimeout by default
+func WithSwarmTimeout(timeout time.Duration) SwarmOption {
+	return func(o *swarmOptions) { o.timeout = timeout }
+}
+
+// swarmSource is the client's gateway or a peer the chunks are downloaded from
+type swarmSource struct {
+	name   string
+	client *Client
+	slots  chan struct{}
+
+	lock     sync.Mutex
+	inFlight int
+	failures int
+}
+
+func (s *swarmSource) load() (inFlight, failures int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.inFlight, s.failures
+}
+
+// SwarmDownloadChunkData downloads the chunks of a transaction from the client's gateway and several peers in parallel.
+// The chunks are spread over the sources, every chunk is verified against the data_root of the transaction, and
+// a chunk that failed is downloaded again from another source. A source failing several chunks is not used anymore.
+func (c *Client) SwarmDownloadChunkData(id string, opts ...SwarmOption) ([]byte, error) {
+	o := &swarmOptions{size: DefaultSwarmPeers, concurrency: DefaultSwarmConcurrency, timeout: DefaultPeerTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.concurrency <= 0 {
+		o.concurrency = DefaultSwarmConcurrency
+	}
+	// the chunks are verified whatever the client is configured for, they come from untrusted peers
+	verifying := *c
+	verifying.verifyChunks = true
+	info, err := verifying.getTxDataInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	if info.dataRoot == nil {
+		return nil, fmt.Errorf("tx %s has no data_root, its chunks can not be verified", id)
+	}
+
+	peers := o.peers
+	if len(peers) == 0 && o.size > 0 {
+		all, err := c.GetPeers()
+		if err != nil {
+			return nil, err
+		}
+		peers = samplePeers(all, o.size)
+	}
+	sources := make([]*swarmSource, 0, len(peers)+1)
+	sources = append(sources, &swarmSource{name: c.url, client: c})
+	for _, peer := range peers {
+		sources = append(sources, &swarmSource{name: peer, client: c.peerClient(peer, o.timeout)})
+	}
+	for _, src := range sources {
+		src.slots = make(chan struct{}, o.concurrency)
+	}
+
+	var pickLock sync.Mutex
+	// pick returns the preferred source of the chunk, or the least busy source it was not tried on
+	pick := func(preferred int, tried map[*swarmSource]bool) *swarmSource {
+		pickLock.Lock()
+		defer pickLock.Unlock()
+		if src := sources[preferred%len(sources)]; !tried[src] {
+			if _, failures := src.load(); failures < maxSwarmSourceFailures {
+				return src
+			}
+		}
+		var best *swarmSource
+		bestLoad := 0
+		for _, src := range sources {
+			inFlight, failures := src.load()
+			if tried[src] || failures >= maxSwarmSourceFailures {
+				continue
+			}
+			if best == nil || inFlight+failures < bestLoad {
+				best, bestLoad = src, inFlight+failures
+			}
+		}
+		return best
+	}
+	// fetch downloads the chunk at pos in the tx data, trying the sources until one serves a valid chunk
+	fetch := func(idx int, pos int64) ([]byte, int64, error) {
+		tried := make(map[*swarmSource]bool)
+		var errs []error
+		for {
+			if err := c.Context().Err(); err != nil {
+				return nil, 0, err
+			}
+			src := pick(idx, tried)
+			if src == nil && len(errs) == 0 {
+				return nil, 0, fmt.Errorf("no source left for offset %d, they all failed too many chunks", info.startOffset+pos)
+			}
+			if src == nil {
+				return nil, 0, fmt.Errorf("no source left for offset %d: %w", info.startOffset+pos, errors.Join(errs...))
+			}
+			tried[src] = true
+			src.slots <- struct{}{}
+			src.lock.Lock()
+			src.inFlight++
+			src.lock.Unlock()
+			data, start, err := src.client.getTxChunk(info, info.startOffset+pos)
+			src.lock.Lock()
+			src.inFlight--
+			if err != nil {
+				src.failures++
+			}
+			src.lock.Unlock()
+			<-src.slots
+			if err == nil {
+				return data, start, nil
+			}
+			errs = append(errs, err)
+			c.log().Warn("swarm chunk failed, try another source", "arId", id, "offset", info.startOffset+pos, "source", src.name, "err", err)
+		}
+	}
+
+	data := make([]byte, info.size)
+	progress := c.downloadProgress(info)
+	var (
+		lock     sync.Mutex
+		wg       sync.WaitGroup
+		firstErr error
+	)
+	p, err := ants.NewPoolWithFunc(o.concurrency*len(sources), func(i interface{}) {
+		defer wg.Done()
+		pos := i.(int64)
+		idx := int(pos / types.MAX_CHUNK_SIZE)
+		chunk, start, err := fetch(idx, pos)
+		if err == nil && start != pos {
+			err = fmt.Errorf("chunk at offset %d does not start at %d", info.startOffset+pos, pos)
+		}
+		if err != nil {
+			progress.chunkFailed(idx, 1, err)
+			lock.Lock()
+			if firstErr == nil {
+				firstErr = err
+			}
+			lock.Unlock()
+			return
+		}
+		copy(data[start:], chunk)
+		progress.chunkDone(idx, len(chunk))
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer p.Release()
+
+	// the MAX_CHUNK_SIZE aligned chunks are downloaded concurrently, the rebalanced last two one by one below
+	for pos := int64(0); pos+2*types.MAX_CHUNK_SIZE < info.size; pos += types.MAX_CHUNK_SIZE {
+		wg.Add(1)
+		if err := p.Invoke(pos); err != nil {
+			wg.Done()
+			return nil, err
+		}
+	}
+	wg.Wait()
+	if err := c.Context().Err(); err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	cursor := int64(0)
+	if info.size > 2*types.MAX_CHUNK_SIZE {
+		cursor = (info.size - 2*types.MAX_CHUNK_SIZE + types.MAX_CHUNK_SIZE - 1) / types.MAX_CHUNK_SIZE * types.MAX_CHUNK_SIZE
+	}
+	for cursor < info.size {
+		idx := int(cursor / types.MAX_CHUNK_SIZE)
+		chunk, start, err := fetch(idx, cursor)
+		if err == nil && start != cursor {
+			err = fmt.Errorf("chunk at offset %d does not start at %d", info.startOffset+cursor, cursor)
+		}
+		if err == nil && len(chunk) == 0 {
+			err = errors.New("empty chunk")
+		}
+		if err != nil {
+			progress.chunkFailed(idx, 1, err)
+			return nil, err
+		}
+		copy(data[start:], chunk)
+		progress.chunkDone(idx, len(chunk))
+		cursor += int64(len(chunk))
+	}
+	return data, nil
+}
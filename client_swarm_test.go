@@ -0,0 +1,65 @@
+package goar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SwarmDownloadChunkData(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 8*types.MAX_CHUNK_SIZE+100)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	var (
+		lock   sync.Mutex
+		served = map[string]int{}
+	)
+	node := func(name string, corrupt int) *httptest.Server {
+		return mockChunkNode(t, id, data, func(idx int, chunk []byte) []byte {
+			lock.Lock()
+			defer lock.Unlock()
+			served[name]++
+			if idx == corrupt {
+				chunk[0]++
+			}
+			return chunk
+		})
+	}
+	// the gateway and the first peer corrupt a chunk each, the second peer is down
+	gateway, peer1 := node("gateway", 2), node("peer1", 5)
+	defer gateway.Close()
+	defer peer1.Close()
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	peers := []string{strings.TrimPrefix(peer1.URL, "http://"), strings.TrimPrefix(down.URL, "http://")}
+
+	c := NewClient(gateway.URL)
+	c.SetRetryPolicy(NoRetry)
+	c.SetChunkVerification(false) // always verified by the swarm
+	// the peers are requested with the hooks of the client
+	hooked := map[string]int{}
+	c.SetHooks(Hooks{RequestFinish: func(ctx context.Context, req RequestInfo, res RequestResult) {
+		lock.Lock()
+		defer lock.Unlock()
+		hooked[strings.SplitN(req.Url, "/", 4)[2]]++
+	}})
+	got, err := c.SwarmDownloadChunkData(id, WithSwarmPeers(peers...), WithSwarmConcurrency(2))
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.True(t, served["gateway"] > 0)
+	assert.True(t, served["peer1"] > 0)
+	assert.True(t, hooked[peers[0]] > 0)
+	assert.True(t, hooked[peers[1]] > 0)
+
+	// no source serves a valid chunk 2
+	_, err = c.SwarmDownloadChunkData(id, WithSwarmPeers(peers[1]))
+	assert.ErrorIs(t, err, ErrInvalidChunk)
+}
@@ -1,12 +1,18 @@
 package goar
 
 import (
-	"github.com/everFinance/goar/types"
-	"github.com/everFinance/goar/utils"
-	"github.com/stretchr/testify/assert"
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strconv"
 	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
 )
 
 // func TestGetTransactionByID(t *testing.T) {
@@ -147,10 +153,10 @@ func TestGetTransaction(t *testing.T) {
 	// not exist tx
 	txId = "KPlEyCrcs2rDHBFn2f0UUn2NZQKfawGb_EnBfip8ayA"
 	txStatus, err = cli.GetTransactionStatus(txId)
-	assert.Equal(t, ErrNotFound, err)
+	assert.ErrorIs(t, err, ErrNotFound)
 	assert.Nil(t, txStatus)
 	tx, err = cli.GetTransactionByID(txId)
-	assert.Equal(t, ErrNotFound, err)
+	assert.ErrorIs(t, err, ErrNotFound)
 	assert.Nil(t, tx)
 
 	// // pending tx
@@ -316,7 +322,7 @@ func TestClient_ConcurrentDownloadChunkData(t *testing.T) {
 	data, err := c.ConcurrentDownloadChunkData(arId, 0)
 	// data , err := c.DownloadChunkData(arId)
 	assert.NoError(t, err)
-	os.WriteFile("nannan.gif", data, 0666)
+	os.WriteFile(filepath.Join(t.TempDir(), "nannan.gif"), data, 0666)
 	chunks, err := utils.GenerateChunks(data)
 	assert.NoError(t, err)
 	dataRoot := utils.Base64Encode(chunks.DataRoot)
@@ -469,3 +475,100 @@ func TestNewWallet2(t *testing.T) {
 		bundleItemStart += itemBinaryLength // next itemBy start offset
 	}
 }
+
+func TestClient_WithContext(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	c := NewClient(srv.URL).WithContext(ctx)
+	_, err := c.DataSyncRecord("100", 1)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the original client stays unbound
+	assert.Equal(t, context.Background(), NewClient(srv.URL).Context())
+}
+
+// mockChunkNode serves the offset, data_root and chunks of a single tx, tamper may modify a chunk before it is served
+func mockChunkNode(t *testing.T, id string, data []byte, tamper func(idx int, chunk []byte) []byte) *httptest.Server {
+	tx := &types.Transaction{}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+	const startOffset = 1000000
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tx/" + id + "/offset":
+			w.Write([]byte(`{"size":"` + strconv.Itoa(len(data)) + `","offset":"` + strconv.Itoa(startOffset+len(data)-1) + `"}`))
+			return
+		case "/tx/" + id + "/data_root":
+			w.Write([]byte(tx.DataRoot))
+			return
+		}
+		offset, err := strconv.Atoi(r.URL.Path[len("/chunk/"):])
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for idx, chunk := range tx.Chunks.Chunks {
+			if offset-startOffset < chunk.MinByteRange || offset-startOffset >= chunk.MaxByteRange {
+				continue
+			}
+			chunkData := append([]byte{}, data[chunk.MinByteRange:chunk.MaxByteRange]...)
+			if tamper != nil {
+				chunkData = tamper(idx, chunkData)
+			}
+			w.Write([]byte(`{"chunk":"` + utils.Base64Encode(chunkData) + `","data_path":"` + utils.Base64Encode(tx.Chunks.Proofs[idx].Proof) + `","tx_path":""}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+}
+
+func TestClient_DownloadChunkData_Verify(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 3*types.MAX_CHUNK_SIZE+100)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+
+	srv := mockChunkNode(t, id, data, nil)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+	got, err := c.DownloadChunkData(id)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+
+	// the second chunk is corrupted by the node
+	calls := 0
+	bad := mockChunkNode(t, id, data, func(idx int, chunk []byte) []byte {
+		if idx == 1 {
+			calls++
+			chunk[0] ^= 0xff
+		}
+		return chunk
+	})
+	defer bad.Close()
+	c = NewClient(bad.URL)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	_, err = c.DownloadChunkData(id)
+	assert.ErrorIs(t, err, ErrInvalidChunk)
+	// a single node is not asked again
+	assert.Equal(t, 1, calls)
+	_, err = c.ConcurrentDownloadChunkData(id, 2)
+	assert.Error(t, err)
+
+	// verification disabled
+	c.SetChunkVerification(false)
+	got, err = c.DownloadChunkData(id)
+	assert.NoError(t, err)
+	assert.NotEqual(t, data, got)
+	assert.Equal(t, len(data), len(got))
+}
@@ -0,0 +1,155 @@
+package goar
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/everFinance/goar/types"
+)
+
+// transaction states reported by WaitForConfirmation
+const (
+	TxStatePending   = "pending"   // in the mempool
+	TxStateNotFound  = "not_found" // not propagated yet, or dropped from the mempool
+	TxStateConfirmed = "confirmed" // mined, maybe with fewer confirmations than required
+)
+
+// a block anchor is only accepted while it is one of the last 50 blocks
+const maxAnchorDepth = 50
+
+// Confirmation is the state of a transaction while waiting for its confirmation
+type Confirmation struct {
+	TxId           string
+	State          string
+	BlockHeight    int64 // set once confirmed
+	BlockIndepHash string
+	Confirmations  int
+	Polls          int
+	Resubmits      int
+}
+
+type WaitOption func(o *waitOptions)
+
+type waitOptions struct {
+	backoff  RetryPolicy
+	resubmit *types.Transaction
+	onPoll   func(Confirmation)
+}
+
+// DefaultWaitBackoff spaces the status polls of WaitForConfirmation, only its delays are used
+func DefaultWaitBackoff() RetryPolicy {
+	return RetryPolicy{
+		BaseDelay:  5 * time.Second,
+		MaxDelay:   2 * time.Minute,
+		Multiplier: 1.5,
+		Jitter:     0.1,
+	}
+}
+
+func WithPollBackoff(policy RetryPolicy) WaitOption {
+	return func(o *waitOptions) { o.backoff = policy }
+}
+
+// WithResubmit re-submits the header of tx when it falls out of the mempool, until its anchor expires
+func WithResubmit(tx *types.Transaction) WaitOption {
+	return func(o *waitOptions) { o.resubmit = tx }
+}
+
+// WithPollFunc is called with the state of the transaction after every poll
+func WithPollFunc(fn func(Confirmation)) WaitOption {
+	return func(o *waitOptions) { o.onPoll = fn }
+}
+
+// WaitForConfirmation polls the status of the transaction until it is mined with at least minConfirmations.
+// It returns the last state of the transaction with ctx's error when ctx is done first,
+// and ErrTxDropped when the transaction re-submitted with WithResubmit expired.
+func (c *Client) WaitForConfirmation(ctx context.Context, txId string, minConfirmations int, opts ...WaitOption) (Confirmation, error) {
+	o := &waitOptions{backoff: DefaultWaitBackoff()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	c = c.WithContext(ctx)
+	conf := Confirmation{TxId: txId}
+	anchorHeight := int64(-1) // unknown
+
+	for poll := 1; ; poll++ {
+		status, err := c.GetTransactionStatus(txId)
+		conf.Polls = poll
+		switch {
+		case err == nil:
+			conf.State = TxStateConfirmed
+			conf.BlockHeight = int64(status.BlockHeight)
+			conf.BlockIndepHash = status.BlockIndepHash
+			conf.Confirmations = status.NumberOfConfirmations
+		case errors.Is(err, ErrPendingTx):
+			conf.State = TxStatePending
+		case errors.Is(err, ErrNotFound):
+			conf.State = TxStateNotFound
+		default:
+			if ctx.Err() != nil {
+				return conf, ctx.Err()
+			}
+			c.log().Warn("get tx status failed", "arId", txId, "err", err)
+			if err := c.sleep(o.backoff.Backoff(poll, 0)); err != nil {
+				return conf, err
+			}
+			continue
+		}
+		if o.onPoll != nil {
+			o.onPoll(conf)
+		}
+
+		if conf.State == TxStateConfirmed && conf.Confirmations >= minConfirmations {
+			return conf, nil
+		}
+		// a transaction is usually not found right after its submission, while it propagates
+		if conf.State == TxStateNotFound && o.resubmit != nil && poll > 1 {
+			ok, err := c.resubmit(o.resubmit, &anchorHeight)
+			if err != nil {
+				return conf, err
+			}
+			if ok {
+				conf.Resubmits++
+			}
+		}
+
+		if err := c.sleep(o.backoff.Backoff(poll, 0)); err != nil {
+			return conf, err
+		}
+	}
+}
+
+// resubmit submits the header of tx again, unless its anchor expired.
+// anchorHeight caches the height of the anchor block, 0 when the anchor is the last tx of the wallet.
+func (c *Client) resubmit(tx *types.Transaction, anchorHeight *int64) (bool, error) {
+	if *anchorHeight < 0 {
+		b, err := c.GetBlockByID(tx.LastTx)
+		switch {
+		case err == nil:
+			*anchorHeight = b.Height
+		case errors.Is(err, ErrNotFound):
+			// the expiry of a wallet anchor can not be told, the gateway rejects it once expired
+			*anchorHeight = 0
+		default:
+			return false, nil
+		}
+	}
+	if *anchorHeight > 0 {
+		info, err := c.GetInfo()
+		if err != nil {
+			return false, nil
+		}
+		if info.Height > *anchorHeight+maxAnchorDepth {
+			return false, ErrTxDropped
+		}
+	}
+
+	status, code, err := c.SubmitTransaction(tx)
+	if err != nil || code != 200 {
+		c.log().Warn("resubmit tx failed", "arId", tx.ID, "status", status, "code", code, "err", err)
+		return false, nil
+	}
+	c.log().Info("tx resubmitted", "arId", tx.ID)
+	return true, nil
+}
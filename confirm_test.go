@@ -0,0 +1,107 @@
+package goar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockTxStatus serves the given status responses of /tx/{id}/status in turn, repeating the last one
+func mockTxStatus(statuses []func(w http.ResponseWriter), infoHeight int64) (*httptest.Server, *int) {
+	var (
+		lock      sync.Mutex
+		polls     int
+		resubmits int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		switch r.URL.Path {
+		case "/tx/txid/status":
+			if polls >= len(statuses) {
+				polls = len(statuses) - 1
+			}
+			statuses[polls](w)
+			polls++
+		case "/tx":
+			resubmits++
+			w.Write([]byte("OK"))
+		case "/block/hash/anchor":
+			json.NewEncoder(w).Encode(types.Block{Height: 100, IndepHash: "anchor"})
+		case "/info":
+			json.NewEncoder(w).Encode(types.NetworkInfo{Height: infoHeight})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return srv, &resubmits
+}
+
+func notFoundStatus(w http.ResponseWriter) { w.WriteHeader(http.StatusNotFound) }
+
+func pendingStatus(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusAccepted)
+	w.Write([]byte("Pending"))
+}
+
+func confirmedStatus(confirmations int) func(w http.ResponseWriter) {
+	return func(w http.ResponseWriter) {
+		json.NewEncoder(w).Encode(types.TxStatus{BlockHeight: 105, BlockIndepHash: "block", NumberOfConfirmations: confirmations})
+	}
+}
+
+func TestClient_WaitForConfirmation(t *testing.T) {
+	srv, resubmits := mockTxStatus([]func(w http.ResponseWriter){
+		notFoundStatus, notFoundStatus, pendingStatus, confirmedStatus(1), confirmedStatus(3),
+	}, 110)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+
+	states := make([]string, 0)
+	conf, err := c.WaitForConfirmation(context.Background(), "txid", 3,
+		WithPollBackoff(RetryPolicy{BaseDelay: time.Millisecond}),
+		WithResubmit(&types.Transaction{ID: "txid", LastTx: "anchor"}),
+		WithPollFunc(func(conf Confirmation) { states = append(states, conf.State) }),
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, TxStateConfirmed, conf.State)
+	assert.Equal(t, int64(105), conf.BlockHeight)
+	assert.Equal(t, "block", conf.BlockIndepHash)
+	assert.Equal(t, 3, conf.Confirmations)
+	assert.Equal(t, 5, conf.Polls)
+	// not resubmitted after the first poll, while the tx propagates
+	assert.Equal(t, 1, conf.Resubmits)
+	assert.Equal(t, 1, *resubmits)
+	assert.Equal(t, []string{TxStateNotFound, TxStateNotFound, TxStatePending, TxStateConfirmed, TxStateConfirmed}, states)
+}
+
+func TestClient_WaitForConfirmation_Dropped(t *testing.T) {
+	// the anchor at height 100 expired
+	srv, resubmits := mockTxStatus([]func(w http.ResponseWriter){notFoundStatus}, 151)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+
+	conf, err := c.WaitForConfirmation(context.Background(), "txid", 1,
+		WithPollBackoff(RetryPolicy{BaseDelay: time.Millisecond}),
+		WithResubmit(&types.Transaction{ID: "txid", LastTx: "anchor"}),
+	)
+	assert.ErrorIs(t, err, ErrTxDropped)
+	assert.Equal(t, TxStateNotFound, conf.State)
+	assert.Equal(t, 0, *resubmits)
+
+	// without WithResubmit it waits until ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	conf, err = c.WaitForConfirmation(ctx, "txid", 1, WithPollBackoff(RetryPolicy{BaseDelay: time.Millisecond}))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, TxStateNotFound, conf.State)
+}
@@ -1,6 +1,14 @@
 package goar
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
 
 var (
 	ErrNotFound     = errors.New("Not Found")
@@ -8,4 +16,126 @@ var (
 	ErrInvalidId    = errors.New("Invalid ArId")
 	ErrBadGateway   = errors.New("Bad Gateway")
 	ErrRequestLimit = errors.New("Arweave gateway request limit")
+	ErrInvalidChunk = errors.New("Invalid chunk")
+	ErrReorgTooDeep = errors.New("Reorg deeper than the block window")
+	ErrTxDropped    = errors.New("Transaction dropped")
+	ErrFeeCeiling   = errors.New("Price above the fee ceiling")
+
+	ErrInvalidTransaction = errors.New("Invalid transaction")
+	ErrInsufficientFunds  = errors.New("Insufficient funds")
+	ErrBroadcastFailed    = errors.New("Broadcast to peers failed")
 )
+
+// InsufficientFundsError is returned by the pre-flight checks when the balance of the wallet is lower
+// than the reward plus the quantity of a transaction, it matches ErrInsufficientFunds
+type InsufficientFundsError struct {
+	Address  string
+	Balance  *big.Int
+	Required *big.Int
+}
+
+func (e *InsufficientFundsError) Error() string {
+	return fmt.Sprintf("%v: %s has %s winston, %s required", ErrInsufficientFunds, e.Address, e.Balance, e.Required)
+}
+
+func (e *InsufficientFundsError) Is(target error) bool {
+	return target == ErrInsufficientFunds
+}
+
+// ChunkUploadError is returned by ConcurrentOnce when some chunks were not acknowledged by the gateway.
+// Failed holds the error of every failed chunk, Skipped the chunks not sent because the upload stopped,
+// and Err the reason it stopped: the context error or a fatal chunk error.
+type ChunkUploadError struct {
+	TxId    string
+	Failed  map[int]error
+	Skipped []int
+	Err     error
+}
+
+func (e *ChunkUploadError) Error() string {
+	failed := make([]int, 0, len(e.Failed))
+	for idx := range e.Failed {
+		failed = append(failed, idx)
+	}
+	sort.Ints(failed)
+	msg := fmt.Sprintf("upload of %s incomplete: %d chunks failed %v, %d skipped %v", e.TxId, len(failed), failed, len(e.Skipped), e.Skipped)
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *ChunkUploadError) Unwrap() error {
+	return e.Err
+}
+
+// APIError describes a failed gateway request.
+// It matches the sentinel errors above with errors.Is, and the transport error in Err, if any.
+type APIError struct {
+	Method     string
+	Endpoint   string // url of the request, including the node that answered it
+	StatusCode int    // 0 when no response was received
+	Code       string // gateway error code, eg: tx_not_found, invalid_proof, disk_full
+	Body       string
+	RetryAfter time.Duration
+	Err        error // transport error
+
+	sentinel error
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%s %s", e.Method, e.Endpoint)
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s: %d %s", msg, e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	if e.Code != "" {
+		msg = fmt.Sprintf("%s: %s", msg, e.Code)
+	} else if e.Body != "" && e.Err == nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Body)
+	}
+	if e.Err != nil {
+		err := e.Err
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) { // its method and url are already in the message
+			err = urlErr.Err
+		}
+		msg = fmt.Sprintf("%s: %v", msg, err)
+	}
+	if e.sentinel != nil {
+		msg = fmt.Sprintf("%s: %v", e.sentinel, msg)
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.sentinel != nil {
+		errs = append(errs, e.sentinel)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+// Temporary reports whether the request may succeed if it is retried, see IsRetryable
+func (e *APIError) Temporary() bool {
+	return IsRetryable(e.StatusCode, []byte(e.Body), e.Err)
+}
+
+// defaultSentinel maps the responses that mean the same thing on every endpoint to a sentinel error
+func defaultSentinel(statusCode int, err error) error {
+	switch {
+	case err != nil:
+		return ErrBadGateway
+	case statusCode == http.StatusAccepted:
+		return ErrPendingTx
+	case statusCode == http.StatusNotFound:
+		return ErrNotFound
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRequestLimit
+	case statusCode >= 500:
+		return ErrBadGateway
+	}
+	return nil
+}
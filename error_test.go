@@ -0,0 +1,57 @@
+package goar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tx/notfound":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"tx_not_found"}`))
+		case "/tx_anchor":
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+
+	_, err := c.GetTransactionByID("notfound")
+	assert.ErrorIs(t, err, ErrNotFound)
+	apiErr := &APIError{}
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "tx_not_found", apiErr.Code)
+	assert.Equal(t, srv.URL+"/tx/notfound", apiErr.Endpoint)
+	assert.False(t, apiErr.Temporary())
+
+	_, err = c.GetTransactionByID("invalid")
+	assert.ErrorIs(t, err, ErrInvalidId)
+
+	_, err = c.GetTransactionAnchor()
+	assert.ErrorIs(t, err, ErrRequestLimit)
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, int64(7), int64(apiErr.RetryAfter.Seconds()))
+	assert.True(t, apiErr.Temporary())
+
+	// the transport error is kept as the cause
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = c.WithContext(ctx).GetTransactionByID("notfound")
+	assert.ErrorIs(t, err, ErrBadGateway)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, strings.Count(err.Error(), srv.URL), err.Error())
+}
@@ -0,0 +1,205 @@
+package goar
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+// Fee is the reward estimated for a transaction, in winston
+type Fee struct {
+	Base      *big.Int // cost of the data
+	NewWallet *big.Int // fee for a target wallet that does not exist yet, 0 otherwise
+	Reward    *big.Int // reward to set, Base and NewWallet adjusted by the fee strategy
+}
+
+// FeeStrategy adjusts the price quoted by the gateway, the sum of Base and NewWallet, into the reward to pay
+type FeeStrategy interface {
+	Reward(c *Client, price *big.Int) (*big.Int, error)
+}
+
+// FeeEstimator estimates the reward of transactions with a fee strategy
+type FeeEstimator struct {
+	c        *Client
+	strategy FeeStrategy
+}
+
+// NewFeeEstimator creates an estimator, a nil strategy pays the price quoted by the gateway
+func NewFeeEstimator(c *Client, strategy FeeStrategy) *FeeEstimator {
+	if strategy == nil {
+		strategy = FixedMultiplier(0)
+	}
+	return &FeeEstimator{c: c, strategy: strategy}
+}
+
+// Estimate returns the fee of a transaction of dataSize bytes, target is optional
+func (e *FeeEstimator) Estimate(dataSize int64, target string) (*Fee, error) {
+	base, err := e.c.GetTransactionPriceBig(dataSize, "")
+	if err != nil {
+		return nil, err
+	}
+	fee := &Fee{Base: base, NewWallet: big.NewInt(0)}
+	if target != "" {
+		price, err := e.c.GetTransactionPriceBig(dataSize, target)
+		if err != nil {
+			return nil, err
+		}
+		if price.Cmp(base) > 0 {
+			fee.NewWallet.Sub(price, base)
+		}
+	}
+
+	price := new(big.Int).Add(fee.Base, fee.NewWallet)
+	fee.Reward, err = e.strategy.Reward(e.c, price)
+	if err != nil {
+		return nil, err
+	}
+	return fee, nil
+}
+
+// FixedMultiplier pays the quoted price increased by speedFactor percent,
+// eg: speedFactor = 10, reward = 1.1 * price
+type FixedMultiplier int64
+
+func (m FixedMultiplier) Reward(_ *Client, price *big.Int) (*big.Int, error) {
+	return speedUp(price, int64(m)), nil
+}
+
+// withSpeedUp increases the reward of strategy by speedFactor percent, under the Max of a Ceiling
+func withSpeedUp(strategy FeeStrategy, speedFactor int64) FeeStrategy {
+	if strategy == nil {
+		strategy = FixedMultiplier(0)
+	}
+	if speedFactor == 0 {
+		return strategy
+	}
+	switch cl := strategy.(type) {
+	case Ceiling:
+		return Ceiling{Strategy: withSpeedUp(cl.Strategy, speedFactor), Max: cl.Max}
+	case *Ceiling:
+		return Ceiling{Strategy: withSpeedUp(cl.Strategy, speedFactor), Max: cl.Max}
+	}
+	return spedUpFee{strategy: strategy, speedFactor: speedFactor}
+}
+
+type spedUpFee struct {
+	strategy    FeeStrategy
+	speedFactor int64
+}
+
+func (s spedUpFee) Reward(c *Client, price *big.Int) (*big.Int, error) {
+	reward, err := s.strategy.Reward(c, price)
+	if err != nil {
+		return nil, err
+	}
+	return speedUp(reward, s.speedFactor), nil
+}
+
+func speedUp(price *big.Int, speedFactor int64) *big.Int {
+	reward := new(big.Int).Mul(price, big.NewInt(100+speedFactor))
+	return reward.Quo(reward, big.NewInt(100))
+}
+
+// PercentileFee pays the quoted price times the multiplier at Percentile of the recent transactions,
+// a multiplier being the reward of a transaction divided by the current price of its data size.
+// The multiplier is never lower than 1. Every sample costs 2 gateway requests.
+type PercentileFee struct {
+	Blocks     int     // number of recent blocks sampled, 10 by default
+	Percentile float64 // in [0, 100], 50 by default
+	MaxSamples int     // 20 by default
+}
+
+func (p PercentileFee) Reward(c *Client, price *big.Int) (*big.Int, error) {
+	blocks, percentile, maxSamples := p.Blocks, p.Percentile, p.MaxSamples
+	if blocks <= 0 {
+		blocks = 10
+	}
+	if percentile <= 0 || percentile > 100 {
+		percentile = 50
+	}
+	if maxSamples <= 0 {
+		maxSamples = 20
+	}
+
+	multipliers, err := recentFeeMultipliers(c, blocks, maxSamples)
+	if err != nil {
+		return nil, err
+	}
+	if len(multipliers) == 0 {
+		return new(big.Int).Set(price), nil
+	}
+	sort.Slice(multipliers, func(i, j int) bool { return multipliers[i].Cmp(multipliers[j]) < 0 })
+	m := multipliers[int(float64(len(multipliers)-1)*percentile/100)]
+	if m.Cmp(big.NewRat(1, 1)) < 0 {
+		return new(big.Int).Set(price), nil
+	}
+
+	// rounded up
+	reward := new(big.Int).Mul(price, m.Num())
+	reward.Add(reward, new(big.Int).Sub(m.Denom(), big.NewInt(1)))
+	return reward.Quo(reward, m.Denom()), nil
+}
+
+// recentFeeMultipliers samples the transactions of the last blocks, from the newest
+func recentFeeMultipliers(c *Client, blocks, maxSamples int) ([]*big.Rat, error) {
+	info, err := c.GetInfo()
+	if err != nil {
+		return nil, err
+	}
+	multipliers := make([]*big.Rat, 0, maxSamples)
+	for height := info.Height; height > info.Height-int64(blocks) && height >= 0; height-- {
+		b, err := c.GetBlockByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range b.Txs {
+			if len(multipliers) >= maxSamples {
+				return multipliers, nil
+			}
+			tx, err := c.GetTransactionByID(id)
+			if err != nil {
+				c.log().Debug("skip fee sample", "arId", id, "err", err)
+				continue
+			}
+			reward, ok1 := new(big.Int).SetString(tx.Reward, 10)
+			size, ok2 := new(big.Int).SetString(tx.DataSize, 10)
+			if !ok1 || !ok2 || !size.IsInt64() {
+				continue
+			}
+			price, err := c.GetTransactionPriceBig(size.Int64(), "")
+			if err != nil {
+				return nil, err
+			}
+			multipliers = append(multipliers, new(big.Rat).SetFrac(reward, price))
+		}
+	}
+	return multipliers, nil
+}
+
+// Ceiling caps the reward of Strategy at Max, a quoted price above Max fails with ErrFeeCeiling
+type Ceiling struct {
+	Strategy FeeStrategy // FixedMultiplier(0) if nil
+	Max      *big.Int    // required
+}
+
+func (cl Ceiling) Reward(c *Client, price *big.Int) (*big.Int, error) {
+	if cl.Max == nil {
+		return nil, errors.New("fee ceiling without Max")
+	}
+	if price.Cmp(cl.Max) > 0 {
+		return nil, fmt.Errorf("%w: price %s, ceiling %s", ErrFeeCeiling, price, cl.Max)
+	}
+	strategy := cl.Strategy
+	if strategy == nil {
+		strategy = FixedMultiplier(0)
+	}
+	reward, err := strategy.Reward(c, price)
+	if err != nil {
+		return nil, err
+	}
+	if reward.Cmp(cl.Max) > 0 {
+		return new(big.Int).Set(cl.Max), nil
+	}
+	return reward, nil
+}
@@ -0,0 +1,104 @@
+package goar
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockPrices quotes 10 winston a byte, plus 1000 for the target "new", and serves a block of txs
+// paying rewards[i] times their price
+func mockPrices(t *testing.T, rewards ...int64) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		switch {
+		case segments[0] == "price":
+			size, _ := new(big.Int).SetString(segments[1], 10)
+			price := new(big.Int).Mul(size, big.NewInt(10))
+			price.Add(price, big.NewInt(1))
+			if len(segments) == 3 && segments[2] == "new" {
+				price.Add(price, big.NewInt(1000))
+			}
+			w.Write([]byte(price.String()))
+		case r.URL.Path == "/info":
+			json.NewEncoder(w).Encode(types.NetworkInfo{Height: 0})
+		case r.URL.Path == "/block/height/0":
+			ids := make([]string, len(rewards))
+			for i := range rewards {
+				ids[i] = fmt.Sprintf("tx%d", i)
+			}
+			json.NewEncoder(w).Encode(types.Block{Height: 0, Txs: ids})
+		case segments[0] == "tx":
+			i, _ := strconv.Atoi(strings.TrimPrefix(segments[1], "tx"))
+			json.NewEncoder(w).Encode(types.Transaction{
+				ID:       segments[1],
+				DataSize: "100",
+				Reward:   strconv.FormatInt(rewards[i]*1001, 10),
+			})
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}))
+}
+
+func TestFeeEstimator(t *testing.T) {
+	srv := mockPrices(t)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	fee, err := NewFeeEstimator(c, nil).Estimate(100, "new")
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", fee.Base.String())
+	assert.Equal(t, "1000", fee.NewWallet.String())
+	assert.Equal(t, "2001", fee.Reward.String())
+
+	fee, err = NewFeeEstimator(c, FixedMultiplier(10)).Estimate(100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "0", fee.NewWallet.String())
+	assert.Equal(t, "1101", fee.Reward.String())
+
+	// does not overflow int64
+	fee, err = NewFeeEstimator(c, nil).Estimate(1<<62, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "46116860184273879041", fee.Reward.String())
+	_, err = c.GetTransactionPrice(1<<62, nil)
+	assert.Error(t, err)
+
+	fee, err = NewFeeEstimator(c, Ceiling{Strategy: FixedMultiplier(50), Max: big.NewInt(1200)}).Estimate(100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1200", fee.Reward.String())
+	_, err = NewFeeEstimator(c, Ceiling{Max: big.NewInt(1000)}).Estimate(100, "")
+	assert.ErrorIs(t, err, ErrFeeCeiling)
+	// a ceiling without Max does not pay anything
+	_, err = NewFeeEstimator(c, Ceiling{Strategy: FixedMultiplier(50)}).Estimate(100, "")
+	assert.Error(t, err)
+}
+
+func TestPercentileFee(t *testing.T) {
+	srv := mockPrices(t, 1, 3, 2)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	fee, err := NewFeeEstimator(c, PercentileFee{Percentile: 50}).Estimate(100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "2002", fee.Reward.String())
+
+	fee, err = NewFeeEstimator(c, PercentileFee{Percentile: 100}).Estimate(100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "3003", fee.Reward.String())
+
+	// recent transactions paying less than the price do not lower the reward
+	srv2 := mockPrices(t, 0)
+	defer srv2.Close()
+	fee, err = NewFeeEstimator(NewClient(srv2.URL), PercentileFee{}).Estimate(100, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "1001", fee.Reward.String())
+}
@@ -0,0 +1,170 @@
+package goar
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/everFinance/goar/types"
+)
+
+// graphQL posts the query with its variables and decodes the `data` of the response into out
+func (c *Client) graphQL(query string, variables map[string]interface{}, out interface{}) error {
+	byQuery, err := json.Marshal(struct {
+		Query     string                 `json:"query"`
+		Variables map[string]interface{} `json:"variables,omitempty"`
+	}{query, variables})
+	if err != nil {
+		return err
+	}
+
+	resp := c.post("graphql", byQuery)
+	if !resp.ok(http.StatusOK) {
+		return resp.apiError(nil)
+	}
+
+	res := struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}{}
+	if err := json.Unmarshal(resp.body, &res); err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return errors.New("graphql: " + res.Errors[0].Message)
+	}
+	return json.Unmarshal(res.Data, out)
+}
+
+// GetTransactionsPage returns the page of transactions matching q after the cursor, "" for the first page
+func (c *Client) GetTransactionsPage(q types.TransactionQuery, after string) (*types.GQLTransactions, error) {
+	query, vars := q.Build(after)
+	res := struct {
+		Transactions types.GQLTransactions `json:"transactions"`
+	}{}
+	if err := c.graphQL(query, vars, &res); err != nil {
+		return nil, err
+	}
+	return &res.Transactions, nil
+}
+
+// GetBlocksPage returns the page of blocks matching q after the cursor, "" for the first page
+func (c *Client) GetBlocksPage(q types.BlockQuery, after string) (*types.GQLBlocks, error) {
+	query, vars := q.Build(after)
+	res := struct {
+		Blocks types.GQLBlocks `json:"blocks"`
+	}{}
+	if err := c.graphQL(query, vars, &res); err != nil {
+		return nil, err
+	}
+	return &res.Blocks, nil
+}
+
+// TransactionIterator walks the pages of a transactions query, eg:
+//
+//	it := c.QueryTransactions(q)
+//	for it.Next() {
+//		tx := it.Transaction()
+//	}
+//	if err := it.Err(); err != nil {}
+type TransactionIterator struct {
+	c      *Client
+	q      types.TransactionQuery
+	edges  []types.GQLTransactionEdge
+	idx    int
+	cursor string
+	more   bool
+	err    error
+}
+
+// QueryTransactions returns an iterator over all the transactions matching q, pages are fetched on demand
+func (c *Client) QueryTransactions(q types.TransactionQuery) *TransactionIterator {
+	return &TransactionIterator{c: c, q: q, idx: -1, more: true}
+}
+
+// Next advances to the next transaction, fetching the next page when needed.
+// It returns false at the end of the results or on error.
+func (it *TransactionIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.idx++
+	for it.idx >= len(it.edges) {
+		if !it.more {
+			return false
+		}
+		page, err := it.c.GetTransactionsPage(it.q, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.edges, it.idx = page.Edges, 0
+		it.more = page.PageInfo.HasNextPage && len(page.Edges) > 0
+	}
+	it.cursor = it.edges[it.idx].Cursor
+	return true
+}
+
+func (it *TransactionIterator) Transaction() types.GQLTransaction {
+	return it.edges[it.idx].Node
+}
+
+// Cursor returns the cursor of the current transaction, a new query can resume after it
+func (it *TransactionIterator) Cursor() string {
+	return it.cursor
+}
+
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// BlockIterator walks the pages of a blocks query, see TransactionIterator
+type BlockIterator struct {
+	c      *Client
+	q      types.BlockQuery
+	edges  []types.GQLBlockEdge
+	idx    int
+	cursor string
+	more   bool
+	err    error
+}
+
+// QueryBlocks returns an iterator over all the blocks matching q, pages are fetched on demand
+func (c *Client) QueryBlocks(q types.BlockQuery) *BlockIterator {
+	return &BlockIterator{c: c, q: q, idx: -1, more: true}
+}
+
+func (it *BlockIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.idx++
+	for it.idx >= len(it.edges) {
+		if !it.more {
+			return false
+		}
+		page, err := it.c.GetBlocksPage(it.q, it.cursor)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.edges, it.idx = page.Edges, 0
+		it.more = page.PageInfo.HasNextPage && len(page.Edges) > 0
+	}
+	it.cursor = it.edges[it.idx].Cursor
+	return true
+}
+
+func (it *BlockIterator) Block() types.GQLBlock {
+	return it.edges[it.idx].Node
+}
+
+func (it *BlockIterator) Cursor() string {
+	return it.cursor
+}
+
+func (it *BlockIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,93 @@
+package goar
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionQuery_Build(t *testing.T) {
+	min := int64(100)
+	q := types.TransactionQuery{
+		Owners: []string{"owner"},
+		Tags:   []types.TagFilter{{Name: "App-Name", Values: []string{"goar"}}},
+		Block:  &types.HeightRange{Min: &min},
+		Sort:   types.SortHeightAsc,
+		First:  10,
+	}
+	query, vars := q.Build("")
+	assert.Contains(t, query, "query($owners: [String!], $tags: [TagFilter!], $block: BlockFilter, $sort: SortOrder, $first: Int)")
+	assert.Contains(t, query, "transactions(owners: $owners, tags: $tags, block: $block, sort: $sort, first: $first)")
+	assert.NotContains(t, query, "$after")
+	assert.Equal(t, 5, len(vars))
+
+	query, vars = q.Build("cursor")
+	assert.Contains(t, query, "after: $after")
+	assert.Equal(t, "cursor", vars["after"])
+
+	by, err := json.Marshal(vars["block"])
+	assert.NoError(t, err)
+	assert.Equal(t, `{"min":100}`, string(by))
+
+	query, vars = types.BlockQuery{}.Build("")
+	assert.Equal(t, "query { blocks { pageInfo { hasNextPage } edges { cursor node { id timestamp height previous } } } }", query)
+	assert.Equal(t, 0, len(vars))
+}
+
+func TestClient_QueryTransactions(t *testing.T) {
+	var afters []interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/graphql", r.URL.Path)
+		req := struct {
+			Query     string                 `json:"query"`
+			Variables map[string]interface{} `json:"variables"`
+		}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		afters = append(afters, req.Variables["after"])
+
+		page, next := 0, true
+		if req.Variables["after"] == "c1" {
+			page, next = 1, false
+		}
+		fmt.Fprintf(w, `{"data":{"transactions":{"pageInfo":{"hasNextPage":%v},"edges":[
+			{"cursor":"c%d","node":{"id":"tx%d","owner":{"address":"addr"},"data":{"size":"10"},"tags":[{"name":"a","value":"b"}],"block":{"height":%d},"bundledIn":null}},
+			{"cursor":"c%d","node":{"id":"tx%d","block":null}}]}}}`, next, 2*page, 2*page, 100+page, 2*page+1, 2*page+1)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	it := c.QueryTransactions(types.TransactionQuery{Owners: []string{"addr"}, First: 2})
+	ids := make([]string, 0)
+	for it.Next() {
+		tx := it.Transaction()
+		ids = append(ids, tx.Id)
+		if tx.Id == "tx2" {
+			assert.Equal(t, "addr", tx.Owner.Address)
+			assert.Equal(t, "10", tx.Data.Size)
+			assert.Equal(t, []types.Tag{{Name: "a", Value: "b"}}, tx.Tags)
+			assert.Equal(t, int64(101), tx.Block.Height)
+			assert.Nil(t, tx.BundledIn)
+		}
+	}
+	assert.NoError(t, it.Err())
+	assert.Equal(t, []string{"tx0", "tx1", "tx2", "tx3"}, ids)
+	assert.Equal(t, "c3", it.Cursor())
+	assert.Equal(t, []interface{}{nil, "c1"}, afters)
+}
+
+func TestClient_QueryBlocks_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":null,"errors":[{"message":"invalid height filter"}]}`))
+	}))
+	defer srv.Close()
+
+	it := NewClient(srv.URL).QueryBlocks(types.BlockQuery{})
+	assert.False(t, it.Next())
+	assert.EqualError(t, it.Err(), "graphql: invalid height filter")
+	assert.False(t, it.Next())
+}
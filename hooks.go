@@ -0,0 +1,75 @@
+package goar
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Hooks instrument the http layer and the chunk transfers of a Client, eg: with Prometheus counters
+// or OpenTelemetry spans. Nil hooks are skipped, the others are called synchronously and must not block.
+type Hooks struct {
+	// RequestStart is called before every attempt of a gateway request.
+	// The context it returns is bound to the http request and passed to RequestFinish.
+	RequestStart func(ctx context.Context, req RequestInfo) context.Context
+	// RequestFinish is called after every attempt of a gateway request
+	RequestFinish func(ctx context.Context, req RequestInfo, res RequestResult)
+	// Chunk is called after every attempt to upload or download a chunk, downloads include their verification
+	Chunk func(ctx context.Context, ev ChunkEvent)
+}
+
+// RequestInfo describes an attempt of a gateway request
+type RequestInfo struct {
+	RequestId string // shared by the retries of a request
+	Method    string
+	Endpoint  string // path with the ids and numbers replaced, eg: /tx/{id}/offset
+	Class     string // rate limiting class, see ClassRead
+	Url       string
+	Attempt   int
+	BytesSent int
+}
+
+// RequestResult is the outcome of an attempt of a gateway request
+type RequestResult struct {
+	StatusCode    int // 0 when no response was received
+	BytesReceived int
+	Duration      time.Duration // including the wait for the rate limiter
+	Err           error
+}
+
+// ChunkEvent is the outcome of an attempt to upload or download a chunk
+type ChunkEvent struct {
+	Op       string // OpUpload or OpDownload
+	TxId     string
+	Offset   int64 // weave offset for downloads, offset in the tx data for uploads
+	Size     int
+	Attempt  int
+	Duration time.Duration
+	Err      error
+}
+
+// SetHooks sets the hooks called around every gateway request and chunk transfer of the client
+func (c *Client) SetHooks(hooks Hooks) {
+	c.hooks = hooks
+}
+
+func (c *Client) chunkHook(ev ChunkEvent) {
+	if c.hooks.Chunk != nil {
+		c.hooks.Chunk(c.Context(), ev)
+	}
+}
+
+// endpointName replaces the ids and numbers of a path so that it can be used as a metric label
+func endpointName(_path string) string {
+	segments := strings.Split(strings.Trim(_path, "/"), "/")
+	for i, s := range segments {
+		switch {
+		case s == "":
+		case strings.Trim(s, "0123456789") == "":
+			segments[i] = "{n}"
+		case len(s) >= 32:
+			segments[i] = "{id}"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,128 @@
+package goar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpointName(t *testing.T) {
+	id := "lt24bnUGms5XLZeVamSPHePl4M2ClpLQyRxZI7weH1k"
+	assert.Equal(t, "/info", endpointName("info"))
+	assert.Equal(t, "/tx/{id}/offset", endpointName("tx/"+id+"/offset"))
+	assert.Equal(t, "/{id}/data", endpointName("/"+id+"/data"))
+	assert.Equal(t, "/chunk/{n}", endpointName("chunk/123456"))
+	assert.Equal(t, "/price/{n}/{id}", endpointName("price/100/"+id))
+	assert.Equal(t, "/block/height/{n}", endpointName("block/height/1000"))
+}
+
+func TestClient_SetHooks(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 2*types.MAX_CHUNK_SIZE+10)
+	srv := mockChunkNode(t, id, data, nil)
+	defer srv.Close()
+
+	type ctxKey struct{}
+	var (
+		lock      sync.Mutex
+		endpoints = map[string]int{}
+		chunks    []ChunkEvent
+	)
+	c := NewClient(srv.URL)
+	c.SetHooks(Hooks{
+		RequestStart: func(ctx context.Context, req RequestInfo) context.Context {
+			return context.WithValue(ctx, ctxKey{}, req.RequestId)
+		},
+		RequestFinish: func(ctx context.Context, req RequestInfo, res RequestResult) {
+			lock.Lock()
+			defer lock.Unlock()
+			assert.Equal(t, req.RequestId, ctx.Value(ctxKey{}))
+			assert.Equal(t, 200, res.StatusCode)
+			assert.True(t, res.BytesReceived > 0)
+			endpoints[req.Method+" "+req.Endpoint]++
+		},
+		Chunk: func(ctx context.Context, ev ChunkEvent) {
+			lock.Lock()
+			chunks = append(chunks, ev)
+			lock.Unlock()
+		},
+	})
+
+	_, err := c.DownloadChunkData(id)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{
+		"GET /tx/mock-tx/offset":    1,
+		"GET /tx/mock-tx/data_root": 1,
+		"GET /chunk/{n}":            3,
+	}, endpoints)
+	assert.Equal(t, 3, len(chunks))
+	size := 0
+	for _, ev := range chunks {
+		assert.Equal(t, OpDownload, ev.Op)
+		assert.Equal(t, id, ev.TxId)
+		assert.NoError(t, ev.Err)
+		size += ev.Size
+	}
+	assert.Equal(t, len(data), size)
+}
+
+func TestTransactionUploader_ChunkHooks(t *testing.T) {
+	data := make([]byte, 4*types.MAX_CHUNK_SIZE)
+	tx := &types.Transaction{ID: "mock-tx", Data: utils.Base64Encode(data)}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+
+	var (
+		lock  sync.Mutex
+		tries = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunk" {
+			gc := &types.GetChunk{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(gc))
+			lock.Lock()
+			tries[gc.Offset]++
+			first := tries[gc.Offset] == 1
+			lock.Unlock()
+			// the first try of the second chunk fails
+			if first && gc.Offset == strconv.Itoa(2*types.MAX_CHUNK_SIZE-1) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	var chunks []ChunkEvent
+	c.SetHooks(Hooks{Chunk: func(ctx context.Context, ev ChunkEvent) {
+		lock.Lock()
+		chunks = append(chunks, ev)
+		lock.Unlock()
+	}})
+	uploader, err := CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	uploader.RetryPolicy = RetryPolicy{MaxAttempts: 3}
+	assert.NoError(t, uploader.ConcurrentOnce(context.Background(), 2))
+
+	// hooks see every attempt
+	assert.Equal(t, 5, len(chunks))
+	failed := 0
+	for _, ev := range chunks {
+		assert.Equal(t, OpUpload, ev.Op)
+		if ev.Err != nil {
+			failed++
+			assert.Equal(t, int64(types.MAX_CHUNK_SIZE), ev.Offset)
+			assert.Equal(t, 1, ev.Attempt)
+		}
+	}
+	assert.Equal(t, 1, failed)
+}
@@ -0,0 +1,29 @@
+package goar
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_SetLogger(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	buf := &bytes.Buffer{}
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 2})
+	c.SetLogger(utils.NewSlogLogger(slog.New(slog.NewTextHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))))
+	_, err := c.GetInfo()
+	assert.Error(t, err)
+	assert.Contains(t, buf.String(), "msg=\"retry request\" reqId=")
+	assert.Contains(t, buf.String(), "statusCode=503")
+	assert.Contains(t, buf.String(), "attempts=2")
+}
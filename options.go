@@ -0,0 +1,224 @@
+package goar
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// ClientOption configures a client created by NewClientWithOptions
+type ClientOption func(o *clientOptions) error
+
+type clientOptions struct {
+	httpClient *http.Client
+	transport  http.RoundTripper
+	proxy      *url.URL
+	tlsConfig  *tls.Config
+	timeout    time.Duration
+	header     http.Header
+	gateways   []string
+	pooled     bool
+	setters    []func(c *Client)
+}
+
+// NewClientWithOptions creates a client of the node or gateway nodeUrl.
+// Unlike NewClient it returns an error on invalid options, and its http client is never shared with other clients.
+func NewClientWithOptions(nodeUrl string, opts ...ClientOption) (*Client, error) {
+	if nodeUrl == "" {
+		return nil, errors.New("nodeUrl can not be empty")
+	}
+	if _, err := url.Parse(nodeUrl); err != nil {
+		return nil, err
+	}
+	o := &clientOptions{header: http.Header{}}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	httpClient, err := o.newHTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		client:  httpClient,
+		url:     nodeUrl,
+		header:  o.header,
+		retry:   DefaultRetryPolicy(),
+		limiter: NewRateLimiter(DefaultRateLimits()),
+
+		verifyChunks: true,
+	}
+	if o.pooled {
+		c.pool = newGatewayPool(append([]string{nodeUrl}, o.gateways...))
+	}
+	for _, set := range o.setters {
+		set(c)
+	}
+	return c, nil
+}
+
+func (o *clientOptions) newHTTPClient() (*http.Client, error) {
+	if o.httpClient != nil {
+		if o.transport != nil || o.proxy != nil || o.tlsConfig != nil {
+			return nil, errors.New("WithHTTPClient can not be combined with WithTransport, WithProxy or WithTLSConfig")
+		}
+		// copied so that SetTimeout does not change the caller's client
+		httpClient := *o.httpClient
+		if o.timeout > 0 {
+			httpClient.Timeout = o.timeout
+		}
+		return &httpClient, nil
+	}
+
+	transport := o.transport
+	if transport == nil {
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	if o.proxy != nil || o.tlsConfig != nil {
+		tr, ok := transport.(*http.Transport)
+		if !ok {
+			return nil, errors.New("WithProxy and WithTLSConfig require the transport to be an *http.Transport")
+		}
+		if tr == o.transport {
+			tr = tr.Clone()
+		}
+		if o.proxy != nil {
+			tr.Proxy = http.ProxyURL(o.proxy)
+		}
+		if o.tlsConfig != nil {
+			tr.TLSClientConfig = o.tlsConfig
+		}
+		transport = tr
+	}
+	return &http.Client{Transport: transport, Timeout: o.timeout}, nil
+}
+
+// WithHTTPClient sends the requests with a copy of httpClient
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(o *clientOptions) error {
+		if httpClient == nil {
+			return errors.New("nil http client")
+		}
+		o.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithTransport sends the requests with a custom RoundTripper
+func WithTransport(transport http.RoundTripper) ClientOption {
+	return func(o *clientOptions) error {
+		if transport == nil {
+			return errors.New("nil transport")
+		}
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithProxy sends the requests through an http proxy
+func WithProxy(proxyUrl string) ClientOption {
+	return func(o *clientOptions) error {
+		u, err := url.Parse(proxyUrl)
+		if err != nil {
+			return fmt.Errorf("invalid proxy url: %w", err)
+		}
+		o.proxy = u
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the transport, eg: custom root CAs
+func WithTLSConfig(config *tls.Config) ClientOption {
+	return func(o *clientOptions) error {
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of every request attempt
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(o *clientOptions) error {
+		if timeout < 0 {
+			return errors.New("negative timeout")
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithHeader adds a header to every request, eg: the API key of a paid gateway
+func WithHeader(key, value string) ClientOption {
+	return func(o *clientOptions) error {
+		o.header.Add(key, value)
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request
+func WithUserAgent(userAgent string) ClientOption {
+	return func(o *clientOptions) error {
+		o.header.Set("User-Agent", userAgent)
+		return nil
+	}
+}
+
+// WithGateways adds gateways to fail over to, see NewPoolClient
+func WithGateways(nodeUrls ...string) ClientOption {
+	return func(o *clientOptions) error {
+		for _, u := range nodeUrls {
+			if _, err := url.Parse(u); err != nil {
+				return err
+			}
+		}
+		o.gateways = append(o.gateways, nodeUrls...)
+		o.pooled = true
+		return nil
+	}
+}
+
+// WithRetryPolicy mirrors SetRetryPolicy
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return withSetter(func(c *Client) { c.SetRetryPolicy(policy) })
+}
+
+// WithRateLimiter sets the client side rate limiter, nil disables rate limiting
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return withSetter(func(c *Client) { c.SetRateLimiter(limiter) })
+}
+
+// WithChunkVerification mirrors SetChunkVerification
+func WithChunkVerification(enable bool) ClientOption {
+	return withSetter(func(c *Client) { c.SetChunkVerification(enable) })
+}
+
+// WithTempDir mirrors SetTempDir
+func WithTempDir(dir string) ClientOption {
+	return withSetter(func(c *Client) { c.SetTempDir(dir) })
+}
+
+// WithProgress mirrors SetProgress
+func WithProgress(fn ProgressFunc) ClientOption {
+	return withSetter(func(c *Client) { c.SetProgress(fn) })
+}
+
+// WithLogger mirrors SetLogger
+func WithLogger(l Logger) ClientOption {
+	return withSetter(func(c *Client) { c.SetLogger(l) })
+}
+
+// WithHooks mirrors SetHooks
+func WithHooks(hooks Hooks) ClientOption {
+	return withSetter(func(c *Client) { c.SetHooks(hooks) })
+}
+
+func withSetter(set func(c *Client)) ClientOption {
+	return func(o *clientOptions) error {
+		o.setters = append(o.setters, set)
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package goar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewClientWithOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "goar-test", r.Header.Get("User-Agent"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+		w.Write([]byte(`{"network":"arweave.N.1","height":100}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClientWithOptions(srv.URL,
+		WithUserAgent("goar-test"),
+		WithHeader("X-Api-Key", "secret"),
+		WithTimeout(time.Second),
+		WithRetryPolicy(NoRetry),
+	)
+	assert.NoError(t, err)
+	assert.NotEqual(t, http.DefaultClient, c.client)
+	assert.Equal(t, time.Second, c.client.Timeout)
+	assert.Equal(t, 1, c.RetryPolicy().MaxAttempts)
+	info, err := c.GetInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), info.Height)
+
+	// the http client passed in is not modified
+	httpClient := &http.Client{}
+	c, err = NewClientWithOptions(srv.URL, WithHTTPClient(httpClient))
+	assert.NoError(t, err)
+	c.SetTimeout(time.Minute)
+	assert.Equal(t, time.Duration(0), httpClient.Timeout)
+
+	_, err = NewClientWithOptions(srv.URL, WithProxy("://bad"))
+	assert.Error(t, err)
+	_, err = NewClientWithOptions(srv.URL, WithHTTPClient(httpClient), WithProxy("http://127.0.0.1:8001"))
+	assert.Error(t, err)
+	_, err = NewClientWithOptions("")
+	assert.Error(t, err)
+
+	// NewClient never uses the shared http client
+	c = NewClient(srv.URL)
+	c.SetTimeout(time.Minute)
+	assert.Equal(t, time.Duration(0), http.DefaultClient.Timeout)
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestNewClientWithOptions_HeaderCopied(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, []string{"secret"}, r.Header.Values("X-Api-Key"))
+		w.Write([]byte(`{"network":"arweave.N.1","height":100}`))
+	}))
+	defer srv.Close()
+
+	// the transport modifies the header of each request, not the one of the client
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c, err := NewClientWithOptions(srv.URL,
+		WithHeader("X-Api-Key", "secret"),
+		WithTransport(roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			assert.Equal(t, "secret", req.Header["X-Api-Key"][0])
+			req.Header["X-Api-Key"][0] = "rotated"
+			req.Header.Set("X-Api-Key", "secret")
+			return transport.RoundTrip(req)
+		})),
+	)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		_, err = c.GetInfo()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"secret"}, c.header.Values("X-Api-Key"))
+}
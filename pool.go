@@ -0,0 +1,162 @@
+package goar
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// weight of the newest sample in the latency and error rate moving averages
+	poolEwmaAlpha = 0.2
+	// an endpoint that failed or answered 429 is skipped for this long, doubled per consecutive failure
+	poolBaseCooldown = 2 * time.Second
+	poolMaxCooldown  = 2 * time.Minute
+)
+
+// EndpointStats is a snapshot of the health of one gateway in a pooled client
+type EndpointStats struct {
+	Url           string
+	Requests      int64
+	Errors        int64
+	RateLimited   int64
+	Latency       time.Duration // moving average of successful requests
+	ErrorRate     float64       // moving average in [0, 1]
+	CooldownUntil time.Time
+}
+
+type endpoint struct {
+	url         string
+	requests    int64
+	errors      int64
+	rateLimited int64
+	latency     float64 // ms
+	errRate     float64
+	failures    int // consecutive
+	cooldown    time.Time
+}
+
+// score is lower for healthier endpoints
+func (e *endpoint) score(now time.Time) float64 {
+	latency := e.latency
+	if latency == 0 {
+		latency = 100 // unknown endpoints are tried with an optimistic default
+	}
+	s := latency * (1 + 10*e.errRate)
+	if now.Before(e.cooldown) {
+		s += 1e9
+	}
+	return s
+}
+
+type gatewayPool struct {
+	lock      sync.Mutex
+	endpoints []*endpoint
+}
+
+func newGatewayPool(urls []string) *gatewayPool {
+	p := &gatewayPool{}
+	for _, u := range urls {
+		p.endpoints = append(p.endpoints, &endpoint{url: u})
+	}
+	return p
+}
+
+// candidates returns the endpoint urls ordered from healthiest to least healthy
+func (p *gatewayPool) candidates() []string {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	now := time.Now()
+	eps := make([]*endpoint, len(p.endpoints))
+	copy(eps, p.endpoints)
+	sort.SliceStable(eps, func(i, j int) bool {
+		return eps[i].score(now) < eps[j].score(now)
+	})
+	urls := make([]string, 0, len(eps))
+	for _, e := range eps {
+		urls = append(urls, e.url)
+	}
+	return urls
+}
+
+func (p *gatewayPool) report(url string, latency time.Duration, statusCode int, err error) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	var e *endpoint
+	for _, ep := range p.endpoints {
+		if ep.url == url {
+			e = ep
+			break
+		}
+	}
+	if e == nil {
+		return
+	}
+
+	e.requests++
+	failed := err != nil || statusCode >= 500
+	limited := statusCode == http.StatusTooManyRequests
+	switch {
+	case failed:
+		e.errors++
+		e.errRate = poolEwmaAlpha + (1-poolEwmaAlpha)*e.errRate
+	case limited:
+		e.rateLimited++
+	default:
+		e.errRate = (1 - poolEwmaAlpha) * e.errRate
+		ms := float64(latency) / float64(time.Millisecond)
+		if e.latency == 0 {
+			e.latency = ms
+		} else {
+			e.latency = poolEwmaAlpha*ms + (1-poolEwmaAlpha)*e.latency
+		}
+	}
+
+	if failed || limited {
+		cooldown := poolBaseCooldown << e.failures
+		if cooldown > poolMaxCooldown || cooldown <= 0 {
+			cooldown = poolMaxCooldown
+		}
+		e.failures++
+		e.cooldown = time.Now().Add(cooldown)
+	} else {
+		e.failures = 0
+		e.cooldown = time.Time{}
+	}
+}
+
+func (p *gatewayPool) stats() []EndpointStats {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	res := make([]EndpointStats, 0, len(p.endpoints))
+	for _, e := range p.endpoints {
+		res = append(res, EndpointStats{
+			Url:           e.url,
+			Requests:      e.requests,
+			Errors:        e.errors,
+			RateLimited:   e.rateLimited,
+			Latency:       time.Duration(e.latency * float64(time.Millisecond)),
+			ErrorRate:     e.errRate,
+			CooldownUntil: e.cooldown,
+		})
+	}
+	return res
+}
+
+// shouldFailover reports whether a request answered by one gateway is worth retrying on another one
+func shouldFailover(method string, statusCode int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if statusCode == http.StatusTooManyRequests || statusCode >= 500 {
+		return true
+	}
+	// gateways do not all hold the same data, another one may have it
+	return method == http.MethodGet && statusCode == http.StatusNotFound
+}
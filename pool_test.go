@@ -0,0 +1,49 @@
+package goar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewPoolClient_Failover(t *testing.T) {
+	bad := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer bad.Close()
+	good := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"network":"arweave.N.1","height":100}`))
+	}))
+	defer good.Close()
+
+	c := NewPoolClient([]string{bad.URL, good.URL})
+	info, err := c.GetInfo()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), info.Height)
+
+	// the failing gateway is cooling down, the next request goes straight to the healthy one
+	_, err = c.GetInfo()
+	assert.NoError(t, err)
+	stats := c.Endpoints()
+	assert.Equal(t, 2, len(stats))
+	assert.Equal(t, int64(1), stats[0].Requests)
+	assert.Equal(t, int64(1), stats[0].Errors)
+	assert.Equal(t, int64(2), stats[1].Requests)
+	assert.Equal(t, int64(0), stats[1].Errors)
+}
+
+func TestNewPoolClient_NoFailoverOnClientError(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	c := NewPoolClient([]string{srv.URL, srv.URL + "/"})
+	_, err := c.GetTransactionByID("invalid")
+	assert.ErrorIs(t, err, ErrInvalidId)
+	assert.Equal(t, 1, calls)
+}
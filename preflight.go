@@ -0,0 +1,116 @@
+package goar
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+)
+
+// SetPreflight enables the checks of Preflight before every transaction is sent, they are on by default
+func (w *Wallet) SetPreflight(enable bool) {
+	w.skipPreflight = !enable
+}
+
+// Preflight checks the transaction with CheckTransaction, and that the wallet can afford its reward and quantity.
+// It only reads from the gateway, the insufficient funds error is an *InsufficientFundsError.
+func (w *Wallet) Preflight(tx *types.Transaction) error {
+	if err := CheckTransaction(tx); err != nil {
+		return err
+	}
+
+	required, _ := new(big.Int).SetString(tx.Reward, 10)
+	if tx.Quantity != "" {
+		quantity, _ := new(big.Int).SetString(tx.Quantity, 10)
+		required.Add(required, quantity)
+	}
+	address := w.Signer.Address
+	balance, err := w.Client.GetWalletWinstonBalance(address)
+	if err != nil {
+		return err
+	}
+	if balance.Cmp(required) < 0 {
+		return &InsufficientFundsError{Address: address, Balance: balance, Required: required}
+	}
+	return nil
+}
+
+// CheckTransaction checks the fields of a transaction before it is signed: its target address, reward and quantity,
+// the size of its tags and the size of its data. The errors match ErrInvalidTransaction.
+func CheckTransaction(tx *types.Transaction) error {
+	invalid := func(format string, a ...interface{}) error {
+		return fmt.Errorf("%w: %s", ErrInvalidTransaction, fmt.Sprintf(format, a...))
+	}
+
+	if tx.Format != 1 && tx.Format != 2 {
+		return invalid("unsupported format %d", tx.Format)
+	}
+	if tx.Target != "" {
+		addr, err := utils.Base64Decode(tx.Target)
+		if err != nil || len(addr) != 32 {
+			return invalid("invalid target address %s", tx.Target)
+		}
+	}
+	reward, ok := new(big.Int).SetString(tx.Reward, 10)
+	if !ok || reward.Sign() < 0 {
+		return invalid("invalid reward %s", tx.Reward)
+	}
+	if tx.Quantity != "" && tx.Quantity != "0" {
+		quantity, ok := new(big.Int).SetString(tx.Quantity, 10)
+		if !ok || quantity.Sign() < 0 {
+			return invalid("invalid quantity %s", tx.Quantity)
+		}
+		if tx.Target == "" {
+			return invalid("quantity %s without target", tx.Quantity)
+		}
+	}
+
+	tags, err := utils.TagsDecode(tx.Tags)
+	if err != nil {
+		return invalid("tags are not base64url encoded: %v", err)
+	}
+	tagsSize := 0
+	for _, tag := range tags {
+		if tag.Name == "" {
+			return invalid("empty tag name")
+		}
+		tagsSize += len(tag.Name) + len(tag.Value)
+	}
+	if tagsSize > types.MAX_TAGS_SIZE {
+		return invalid("tags size %d exceeds %d bytes", tagsSize, types.MAX_TAGS_SIZE)
+	}
+
+	dataSize := int64(0)
+	if tx.DataSize != "" {
+		dataSize, err = strconv.ParseInt(tx.DataSize, 10, 64)
+		if err != nil || dataSize < 0 {
+			return invalid("invalid data_size %s", tx.DataSize)
+		}
+	}
+	actualSize := int64(0)
+	if tx.DataReader != nil {
+		if tx.Format == 1 {
+			return invalid("format 1 data can not be streamed")
+		}
+		info, err := tx.DataReader.Stat()
+		if err != nil {
+			return err
+		}
+		actualSize = info.Size()
+	} else if tx.Data != "" {
+		data, err := utils.Base64Decode(tx.Data)
+		if err != nil {
+			return invalid("data is not base64url encoded: %v", err)
+		}
+		actualSize = int64(len(data))
+	}
+	if actualSize != dataSize {
+		return invalid("data_size %d does not match the size of the data %d", dataSize, actualSize)
+	}
+	if tx.Format == 1 && dataSize > types.MAX_TX_DATA_SIZE_V1 {
+		return invalid("format 1 data size %d exceeds %d bytes", dataSize, types.MAX_TX_DATA_SIZE_V1)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package goar
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTransaction(t *testing.T) {
+	target := utils.Base64Encode(make([]byte, 32))
+	valid := func() *types.Transaction {
+		return &types.Transaction{
+			Format:   2,
+			Target:   target,
+			Quantity: "100",
+			Tags:     utils.TagsEncode([]types.Tag{{Name: "App-Name", Value: "goar"}}),
+			Data:     utils.Base64Encode([]byte("data")),
+			DataSize: "4",
+			Reward:   "10",
+		}
+	}
+	assert.NoError(t, CheckTransaction(valid()))
+
+	for name, modify := range map[string]func(tx *types.Transaction){
+		"format":          func(tx *types.Transaction) { tx.Format = 3 },
+		"target":          func(tx *types.Transaction) { tx.Target = "abc" },
+		"reward":          func(tx *types.Transaction) { tx.Reward = "" },
+		"quantity":        func(tx *types.Transaction) { tx.Quantity = "-1" },
+		"quantity target": func(tx *types.Transaction) { tx.Target = "" },
+		"tags encoding":   func(tx *types.Transaction) { tx.Tags = []types.Tag{{Name: "App Name", Value: "goar"}} },
+		"tags size": func(tx *types.Transaction) {
+			tx.Tags = utils.TagsEncode([]types.Tag{{Name: "a", Value: strings.Repeat("a", 2048)}})
+		},
+		"data size": func(tx *types.Transaction) { tx.DataSize = "5" },
+		"format 1 size": func(tx *types.Transaction) {
+			tx.Format, tx.DataSize, tx.Data = 1, "10485761", utils.Base64Encode(make([]byte, 10485761))
+		},
+		"invalid data_size": func(tx *types.Transaction) { tx.DataSize = "four" },
+	} {
+		tx := valid()
+		modify(tx)
+		assert.ErrorIs(t, CheckTransaction(tx), ErrInvalidTransaction, name)
+	}
+}
+
+func TestWallet_Preflight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/balance"):
+			w.Write([]byte("1000"))
+		case strings.HasPrefix(r.URL.Path, "/price/"):
+			w.Write([]byte("999"))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer srv.Close()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	w := &Wallet{Client: NewClient(srv.URL), Signer: NewSignerByPrivateKey(key)}
+
+	tx := &types.Transaction{Format: 2, Target: utils.Base64Encode(make([]byte, 32)), Quantity: "1", DataSize: "0", Reward: "999"}
+	assert.NoError(t, w.Preflight(tx))
+
+	// reward + quantity > balance, nothing is sent
+	_, err = w.SendWinston(big.NewInt(2), tx.Target, nil)
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	var fundsErr *InsufficientFundsError
+	assert.True(t, errors.As(err, &fundsErr))
+	assert.Equal(t, w.Signer.Address, fundsErr.Address)
+	assert.Equal(t, "1000", fundsErr.Balance.String())
+	assert.Equal(t, "1001", fundsErr.Required.String())
+
+	_, err = w.SendData([]byte("data"), []types.Tag{{Name: "", Value: "empty name"}})
+	assert.ErrorIs(t, err, ErrInvalidTransaction)
+}
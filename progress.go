@@ -0,0 +1,109 @@
+package goar
+
+import (
+	"sync"
+	"time"
+)
+
+// operations reported by progress events
+const (
+	OpUpload    = "upload"
+	OpDownload  = "download"
+	OpBroadcast = "broadcast"
+)
+
+// progress event types
+const (
+	ProgressChunk = "chunk" // a chunk was transferred
+	ProgressRetry = "retry" // a chunk transfer failed and is retried
+	ProgressError = "error" // a chunk transfer failed for good
+	ProgressPeer  = "peer"  // a peer was tried by BroadcastData, Err is set if it failed
+)
+
+// ProgressEvent reports the progress of an upload, download or broadcast
+type ProgressEvent struct {
+	Op          string
+	Type        string
+	TxId        string
+	ChunkIndex  int // -1 for peer events
+	TotalChunks int
+	Bytes       int64 // transferred so far
+	TotalBytes  int64
+	Attempt     int    // of the chunk transfer, for retry and error events
+	Peer        string // for peer events
+	Err         error
+	Elapsed     time.Duration
+	ETA         time.Duration // estimated time left, 0 when unknown
+}
+
+// ProgressFunc receives progress events.
+// Events of one transfer are delivered one at a time from the transferring goroutines, so it must not block.
+type ProgressFunc func(ProgressEvent)
+
+// progressTracker emits the events of one transfer, a nil tracker emits nothing
+type progressTracker struct {
+	fn          ProgressFunc
+	op          string
+	txId        string
+	totalBytes  int64
+	totalChunks int
+	start       time.Time
+
+	lock  sync.Mutex
+	base  int64 // bytes transferred before the tracker started, not counted in the ETA
+	bytes int64
+}
+
+func newProgressTracker(fn ProgressFunc, op, txId string, totalBytes int64, totalChunks int) *progressTracker {
+	if fn == nil {
+		return nil
+	}
+	return &progressTracker{fn: fn, op: op, txId: txId, totalBytes: totalBytes, totalChunks: totalChunks, start: time.Now()}
+}
+
+// resumeAt sets the bytes already transferred by a previous run
+func (p *progressTracker) resumeAt(bytes int64) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	p.base, p.bytes = bytes, bytes
+	p.lock.Unlock()
+}
+
+func (p *progressTracker) chunkDone(idx, size int) {
+	p.emit(ProgressEvent{Type: ProgressChunk, ChunkIndex: idx}, int64(size))
+}
+
+func (p *progressTracker) chunkRetry(idx, attempt int, err error) {
+	p.emit(ProgressEvent{Type: ProgressRetry, ChunkIndex: idx, Attempt: attempt, Err: err}, 0)
+}
+
+func (p *progressTracker) chunkFailed(idx, attempt int, err error) {
+	p.emit(ProgressEvent{Type: ProgressError, ChunkIndex: idx, Attempt: attempt, Err: err}, 0)
+}
+
+func (p *progressTracker) peerDone(peer string, size int, err error) {
+	if err != nil {
+		size = 0
+	}
+	p.emit(ProgressEvent{Type: ProgressPeer, ChunkIndex: -1, Peer: peer, Err: err}, int64(size))
+}
+
+func (p *progressTracker) emit(ev ProgressEvent, transferred int64) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.bytes += transferred
+	ev.Op, ev.TxId = p.op, p.txId
+	ev.TotalChunks, ev.TotalBytes = p.totalChunks, p.totalBytes
+	ev.Bytes = p.bytes
+	ev.Elapsed = time.Since(p.start)
+	if done := p.bytes - p.base; done > 0 && p.totalBytes > p.bytes {
+		ev.ETA = time.Duration(float64(ev.Elapsed) / float64(done) * float64(p.totalBytes-p.bytes))
+	}
+	p.fn(ev)
+}
@@ -0,0 +1,100 @@
+package goar
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_DownloadProgress(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 6*types.MAX_CHUNK_SIZE+10)
+	srv := mockChunkNode(t, id, data, nil)
+	defer srv.Close()
+
+	var (
+		lock   sync.Mutex
+		events []ProgressEvent
+	)
+	c := NewClient(srv.URL)
+	c.SetProgress(func(ev ProgressEvent) {
+		lock.Lock()
+		events = append(events, ev)
+		lock.Unlock()
+	})
+
+	_, err := c.ConcurrentDownloadChunkData(id, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 7, len(events))
+	last := events[len(events)-1]
+	assert.Equal(t, OpDownload, last.Op)
+	assert.Equal(t, id, last.TxId)
+	assert.Equal(t, int64(len(data)), last.Bytes)
+	assert.Equal(t, int64(len(data)), last.TotalBytes)
+	assert.Equal(t, 7, last.TotalChunks)
+	for _, ev := range events {
+		assert.Equal(t, ProgressChunk, ev.Type)
+	}
+}
+
+func TestTransactionUploader_Progress(t *testing.T) {
+	data := make([]byte, 4*types.MAX_CHUNK_SIZE)
+	tx := &types.Transaction{ID: "mock-tx", Data: utils.Base64Encode(data)}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+
+	var (
+		lock  sync.Mutex
+		tries = map[string]int{}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/chunk" {
+			gc := &types.GetChunk{}
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(gc))
+			lock.Lock()
+			tries[gc.Offset]++
+			first := tries[gc.Offset] == 1
+			lock.Unlock()
+			// the first try of the second chunk fails
+			if first && gc.Offset == strconv.Itoa(2*types.MAX_CHUNK_SIZE-1) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	uploader, err := CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	uploader.RetryPolicy = RetryPolicy{MaxAttempts: 3}
+	var events []ProgressEvent
+	uploader.Progress = func(ev ProgressEvent) {
+		events = append(events, ev)
+	}
+	assert.NoError(t, uploader.ConcurrentOnce(context.Background(), 2))
+
+	retries, chunks := 0, 0
+	for _, ev := range events {
+		assert.Equal(t, OpUpload, ev.Op)
+		switch ev.Type {
+		case ProgressRetry:
+			retries++
+			assert.Equal(t, 1, ev.ChunkIndex)
+			assert.ErrorIs(t, ev.Err, ErrBadGateway)
+		case ProgressChunk:
+			chunks++
+		}
+	}
+	assert.Equal(t, 1, retries)
+	assert.Equal(t, 4, chunks)
+	assert.Equal(t, int64(len(data)), events[len(events)-1].Bytes)
+}
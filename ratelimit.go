@@ -0,0 +1,143 @@
+package goar
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// endpoint classes limited separately by the RateLimiter
+const (
+	ClassRead        = "read"
+	ClassWrite       = "write" // POST /tx and other writes
+	ClassChunkUpload = "chunk_upload"
+	ClassGraphQL     = "graphql"
+)
+
+// RateLimit configures the token bucket of an endpoint class
+type RateLimit struct {
+	Rate    float64 // requests per second when no 429 is received
+	Burst   int
+	MinRate float64 // the rate never drops below MinRate when backing off
+}
+
+// DefaultRateLimits are the limits used by NewClient
+func DefaultRateLimits() map[string]RateLimit {
+	return map[string]RateLimit{
+		ClassRead:        {Rate: 50, Burst: 50, MinRate: 1},
+		ClassWrite:       {Rate: 20, Burst: 20, MinRate: 0.5},
+		ClassChunkUpload: {Rate: 50, Burst: 50, MinRate: 1},
+		ClassGraphQL:     {Rate: 10, Burst: 10, MinRate: 0.5},
+	}
+}
+
+// RateLimiter is a token bucket per endpoint class.
+// The rate of a class is halved on every 429 and grows back by 5% of its limit on every success.
+type RateLimiter struct {
+	lock    sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	limit  RateLimit
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter creates a limiter, classes absent from limits are not limited
+func NewRateLimiter(limits map[string]RateLimit) *RateLimiter {
+	l := &RateLimiter{buckets: make(map[string]*bucket)}
+	for class, limit := range limits {
+		if limit.Rate <= 0 {
+			continue
+		}
+		if limit.Burst < 1 {
+			limit.Burst = 1
+		}
+		if limit.MinRate <= 0 || limit.MinRate > limit.Rate {
+			limit.MinRate = limit.Rate
+		}
+		l.buckets[class] = &bucket{limit: limit, rate: limit.Rate, tokens: float64(limit.Burst), last: time.Now()}
+	}
+	return l
+}
+
+// Wait blocks until a request of the class may be sent or ctx is done
+func (l *RateLimiter) Wait(ctx context.Context, class string) error {
+	for {
+		l.lock.Lock()
+		b, ok := l.buckets[class]
+		if !ok {
+			l.lock.Unlock()
+			return nil
+		}
+		b.refill(time.Now())
+		if b.tokens >= 1 {
+			b.tokens--
+			l.lock.Unlock()
+			return nil
+		}
+		wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+		l.lock.Unlock()
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// OnResponse adapts the rate of the class to the status code of a response
+func (l *RateLimiter) OnResponse(class string, statusCode int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	b, ok := l.buckets[class]
+	if !ok {
+		return
+	}
+	b.refill(time.Now())
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		b.rate = math.Max(b.rate/2, b.limit.MinRate)
+		b.tokens = math.Min(b.tokens, 0)
+	case statusCode >= 200 && statusCode < 300:
+		b.rate = math.Min(b.rate+b.limit.Rate*0.05, b.limit.Rate)
+	}
+}
+
+// Rate returns the current rate of the class in requests per second, 0 if it is not limited
+func (l *RateLimiter) Rate(class string) float64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if b, ok := l.buckets[class]; ok {
+		return b.rate
+	}
+	return 0
+}
+
+func (b *bucket) refill(now time.Time) {
+	b.tokens = math.Min(b.tokens+now.Sub(b.last).Seconds()*b.rate, float64(b.limit.Burst))
+	b.last = now
+}
+
+// endpointClass classifies a request path for rate limiting
+func endpointClass(method, _path string) string {
+	_path = strings.TrimPrefix(_path, "/")
+	switch {
+	case strings.HasPrefix(_path, "graphql"):
+		return ClassGraphQL
+	case method == http.MethodPost && strings.HasPrefix(_path, "chunk"):
+		return ClassChunkUpload
+	case method == http.MethodPost:
+		return ClassWrite
+	}
+	return ClassRead
+}
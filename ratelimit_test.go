@@ -0,0 +1,60 @@
+package goar
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := NewRateLimiter(map[string]RateLimit{
+		ClassRead: {Rate: 100, Burst: 2, MinRate: 10},
+	})
+
+	// burst is free, then requests are paced
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, l.Wait(context.Background(), ClassRead))
+	}
+	assert.True(t, time.Since(start) >= 15*time.Millisecond)
+
+	// unlimited class
+	assert.NoError(t, l.Wait(context.Background(), ClassGraphQL))
+	assert.Equal(t, float64(0), l.Rate(ClassGraphQL))
+
+	// multiplicative decrease, additive increase
+	l.OnResponse(ClassRead, http.StatusTooManyRequests)
+	assert.Equal(t, float64(50), l.Rate(ClassRead))
+	for i := 0; i < 10; i++ {
+		l.OnResponse(ClassRead, http.StatusTooManyRequests)
+	}
+	assert.Equal(t, float64(10), l.Rate(ClassRead))
+	l.OnResponse(ClassRead, http.StatusOK)
+	assert.Equal(t, float64(15), l.Rate(ClassRead))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.Wait(ctx, ClassRead), context.Canceled)
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+	_, err := c.GraphQL("{}")
+	assert.ErrorIs(t, err, ErrRequestLimit)
+	assert.Equal(t, DefaultRateLimits()[ClassGraphQL].Rate/2, c.RateLimiter().Rate(ClassGraphQL))
+	assert.Equal(t, DefaultRateLimits()[ClassRead].Rate, c.RateLimiter().Rate(ClassRead))
+
+	assert.Equal(t, ClassChunkUpload, endpointClass(http.MethodPost, "chunk"))
+	assert.Equal(t, ClassRead, endpointClass(http.MethodGet, "chunk/1"))
+	assert.Equal(t, ClassWrite, endpointClass(http.MethodPost, "tx"))
+}
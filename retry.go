@@ -0,0 +1,162 @@
+package goar
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/tidwall/gjson"
+)
+
+// RetryPolicy decides whether and when a failed network operation is attempted again.
+// It is shared by the Client http layer, the TransactionUploader and the chunk download paths.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts including the first one;
+	// 1 disables retries and 0 selects the default policy of the caller
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, it grows by Multiplier on every attempt up to MaxDelay
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+	Multiplier float64
+	// Jitter is the fraction of the delay randomly subtracted, in [0, 1]
+	Jitter float64
+	// Retryable overrides the default classification of a failed attempt, see IsRetryable
+	Retryable func(statusCode int, body []byte, err error) bool
+}
+
+// NoRetry performs every operation exactly once
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// DefaultRetryPolicy is used by clients unless configured otherwise
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    20 * time.Second,
+		Multiplier:  2,
+		Jitter:      0.3,
+	}
+}
+
+// DefaultUploadRetryPolicy is used by the TransactionUploader to retry chunk and tx submissions.
+// Uploads are long running, so it keeps trying for a long time before giving up.
+func DefaultUploadRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 100,
+		BaseDelay:   1 * time.Second,
+		MaxDelay:    types.ERROR_DELAY * time.Millisecond,
+		Multiplier:  2,
+		Jitter:      0.3,
+	}
+}
+
+func (p RetryPolicy) orDefault(def RetryPolicy) RetryPolicy {
+	if p.MaxAttempts == 0 {
+		return def
+	}
+	return p
+}
+
+// ShouldRetry reports whether an attempt that failed with the given response should be retried
+func (p RetryPolicy) ShouldRetry(statusCode int, body []byte, err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(statusCode, body, err)
+	}
+	return IsRetryable(statusCode, body, err)
+}
+
+// Backoff returns the delay before the given retry (1 for the first retry).
+// A Retry-After returned by the gateway takes precedence when it is longer, MaxDelay caps both.
+func (p RetryPolicy) Backoff(retry int, retryAfter time.Duration) time.Duration {
+	if retry < 1 {
+		retry = 1
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	delay := float64(p.BaseDelay) * math.Pow(multiplier, float64(retry-1))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay -= delay * math.Min(p.Jitter, 1) * rand.Float64()
+	}
+	if d := time.Duration(delay); retryAfter < d {
+		return d
+	}
+	if p.MaxDelay > 0 && retryAfter > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return retryAfter
+}
+
+// transient gateway errors worth retrying, see types.ERROR_DELAY
+var transientGatewayErrors = map[string]struct{}{
+	"not_joined":                   {},
+	"timeout":                      {},
+	"data_root_not_found":          {},
+	"exceeds_disk_pool_size_limit": {},
+}
+
+// IsRetryable is the default retry classification: network errors, 408, 429, 5xx
+// and the transient chunk errors are retried, FATAL_CHUNK_UPLOAD_ERRORS and everything else is not.
+func IsRetryable(statusCode int, body []byte, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if IsFatalChunkError(body) {
+		return false
+	}
+	switch {
+	case statusCode == http.StatusRequestTimeout, statusCode == http.StatusTooManyRequests, statusCode >= 500:
+		return true
+	case statusCode >= 200 && statusCode < 300:
+		return false
+	}
+	_, ok := transientGatewayErrors[gatewayErrorCode(body)]
+	return ok
+}
+
+// IsFatalChunkError reports whether a /chunk response body is one of types.FATAL_CHUNK_UPLOAD_ERRORS
+func IsFatalChunkError(body []byte) bool {
+	if _, ok := types.FATAL_CHUNK_UPLOAD_ERRORS[string(body)]; ok {
+		return true
+	}
+	code := gatewayErrorCode(body)
+	if code == "" {
+		return false
+	}
+	_, ok := types.FATAL_CHUNK_UPLOAD_ERRORS[`{"error":"`+code+`"}`]
+	return ok
+}
+
+// gatewayErrorCode extracts the error code of a `{"error":"..."}` body
+func gatewayErrorCode(body []byte) string {
+	if !gjson.ValidBytes(body) {
+		return ""
+	}
+	return gjson.GetBytes(body, "error").String()
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds or as an http date
+func parseRetryAfter(header http.Header) time.Duration {
+	v := header.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+package goar
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond, Multiplier: 2}
+	assert.Equal(t, 100*time.Millisecond, p.Backoff(1, 0))
+	assert.Equal(t, 200*time.Millisecond, p.Backoff(2, 0))
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(3, 0))
+	// Retry-After wins when it is longer, up to MaxDelay
+	assert.Equal(t, 250*time.Millisecond, p.Backoff(1, 250*time.Millisecond))
+	assert.Equal(t, 300*time.Millisecond, p.Backoff(1, 24*time.Hour))
+	assert.Equal(t, 2*time.Second, RetryPolicy{BaseDelay: 100 * time.Millisecond}.Backoff(1, 2*time.Second))
+
+	p.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := p.Backoff(1, 0)
+		assert.True(t, d >= 50*time.Millisecond && d <= 100*time.Millisecond)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, IsRetryable(0, nil, http.ErrHandlerTimeout))
+	assert.True(t, IsRetryable(429, nil, nil))
+	assert.True(t, IsRetryable(503, nil, nil))
+	assert.True(t, IsRetryable(400, []byte(`{"error":"data_root_not_found"}`), nil))
+	assert.False(t, IsRetryable(400, []byte(`{"error":"invalid_proof"}`), nil))
+	assert.False(t, IsRetryable(404, nil, nil))
+	assert.False(t, IsRetryable(200, nil, nil))
+	assert.True(t, IsFatalChunkError([]byte(`{"error":"disk_full"}`)))
+	assert.True(t, IsFatalChunkError([]byte(`{"error": "disk_full"}`)))
+	assert.False(t, IsFatalChunkError([]byte(`{"error":"timeout"}`)))
+}
+
+func TestClient_RetryPolicy(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("anchor"))
+	}))
+	defer srv.Close()
+
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	anchor, err := c.GetTransactionAnchor()
+	assert.NoError(t, err)
+	assert.Equal(t, "anchor", anchor)
+	assert.Equal(t, 3, calls)
+
+	calls = 0
+	c.SetRetryPolicy(NoRetry)
+	_, err = c.GetTransactionAnchor()
+	assert.Error(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+func TestTransactionUploader_UploadChunkErrors(t *testing.T) {
+	data := make([]byte, 2*types.MAX_CHUNK_SIZE)
+	tx := &types.Transaction{ID: "mock-tx", DataSize: "524288", Data: utils.Base64Encode(data)}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+
+	body := `{"error":"unknown"}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(body))
+	}))
+	defer srv.Close()
+
+	uploader, err := CreateUploader(NewClient(srv.URL), tx, nil)
+	assert.NoError(t, err)
+	uploader.TxPosted = true
+	uploader.RetryPolicy = RetryPolicy{MaxAttempts: 3}
+	// an unlisted error is retried by the next call
+	assert.NoError(t, uploader.UploadChunk())
+	assert.Equal(t, 400, uploader.LastResponseStatus)
+	assert.Equal(t, 0, uploader.ChunkIndex)
+
+	// a fatal chunk error aborts the upload
+	body = `{"error":"invalid_proof"}`
+	assert.Error(t, uploader.UploadChunk())
+}
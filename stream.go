@@ -0,0 +1,127 @@
+package goar
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/everFinance/goar/types"
+)
+
+// StreamChunkData writes the data of the tx to w chunk by chunk, in order.
+// Up to concurrentNum chunks are downloaded ahead of the writer, so memory use is bounded
+// to concurrentNum chunks whatever the size of the tx. It returns the number of bytes written.
+func (c *Client) StreamChunkData(id string, w io.Writer, concurrentNum int) (int64, error) {
+	info, err := c.getTxDataInfo(id)
+	if err != nil {
+		return 0, err
+	}
+	return c.streamChunks(info, w, concurrentNum)
+}
+
+// ChunkDataReader returns a reader streaming the data of the tx, see StreamChunkData.
+// The caller must close the reader, closing it before EOF stops the download.
+func (c *Client) ChunkDataReader(id string, concurrentNum int) (io.ReadCloser, error) {
+	info, err := c.getTxDataInfo(id)
+	if err != nil {
+		return nil, err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := c.streamChunks(info, pw, concurrentNum)
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+func (c *Client) streamChunks(info *txDataInfo, w io.Writer, concurrentNum int) (written int64, err error) {
+	if concurrentNum <= 0 {
+		concurrentNum = types.DEFAULT_CHUNK_CONCURRENT_NUM
+	}
+
+	// all chunks but the last two are MAX_CHUNK_SIZE, they are fetched ahead concurrently.
+	// the last two may be rebalanced by the uploader and are fetched one after another
+	offsets := make([]int64, 0, info.size/types.MAX_CHUNK_SIZE+1)
+	for offset := info.startOffset; offset <= info.endOffset; offset += types.MAX_CHUNK_SIZE {
+		offsets = append(offsets, offset)
+	}
+	next := info.startOffset
+	if len(offsets) > 3 {
+		offsets = offsets[:len(offsets)-2]
+		next = offsets[len(offsets)-1] + types.MAX_CHUNK_SIZE
+	} else {
+		offsets = nil
+	}
+
+	progress := c.downloadProgress(info)
+	idx := 0
+	write := func(data []byte) error {
+		if len(data) == 0 {
+			return errors.New("empty chunk")
+		}
+		n, err := w.Write(data)
+		written += int64(n)
+		if err == nil {
+			progress.chunkDone(idx, n)
+			idx++
+		}
+		return err
+	}
+
+	// the queue holds the pending chunks in order, its capacity bounds the chunks downloaded ahead
+	queue := make(chan chan chunkResult, concurrentNum)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(queue)
+		for _, offset := range offsets {
+			res := make(chan chunkResult, 1)
+			select {
+			case queue <- res:
+			case <-done:
+				return
+			}
+			go func(offset int64) {
+				data, err := c.getTxChunkData(info, offset)
+				res <- chunkResult{data: data, err: err}
+			}(offset)
+		}
+	}()
+
+	for res := range queue {
+		r := <-res
+		if r.err != nil {
+			progress.chunkFailed(idx, 1, r.err)
+			return written, r.err
+		}
+		if err = write(r.data); err != nil {
+			return written, err
+		}
+	}
+
+	for next <= info.endOffset {
+		if err = c.Context().Err(); err != nil {
+			return written, err
+		}
+		var data []byte
+		data, err = c.getTxChunkData(info, next)
+		if err != nil {
+			progress.chunkFailed(idx, 1, err)
+			return written, err
+		}
+		if err = write(data); err != nil {
+			return written, err
+		}
+		next += int64(len(data))
+	}
+
+	if written != info.size {
+		return written, fmt.Errorf("data size mismatch, expect: %d, got: %d", info.size, written)
+	}
+	return written, nil
+}
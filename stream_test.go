@@ -0,0 +1,79 @@
+package goar
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_StreamChunkData(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 7*types.MAX_CHUNK_SIZE+1000)
+	for i := range data {
+		data[i] = byte(i * 13)
+	}
+	srv := mockChunkNode(t, id, data, nil)
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	buf := &bytes.Buffer{}
+	n, err := c.StreamChunkData(id, buf, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, data, buf.Bytes())
+
+	r, err := c.ChunkDataReader(id, 2)
+	assert.NoError(t, err)
+	got, err := io.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+	assert.NoError(t, r.Close())
+
+	// closing early stops the download
+	r, err = c.ChunkDataReader(id, 2)
+	assert.NoError(t, err)
+	_, err = io.ReadFull(r, make([]byte, 10))
+	assert.NoError(t, err)
+	assert.NoError(t, r.Close())
+
+	dir := t.TempDir()
+	c.SetTempDir(dir)
+	f, err := c.DownloadChunkDataStream(id)
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, dir, filepath.Dir(f.Name()))
+	got, err = os.ReadFile(f.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, data, got)
+}
+
+func TestClient_StreamChunkData_Invalid(t *testing.T) {
+	id := "mock-tx"
+	data := make([]byte, 5*types.MAX_CHUNK_SIZE)
+	srv := mockChunkNode(t, id, data, func(idx int, chunk []byte) []byte {
+		if idx == 2 {
+			chunk[0] = 1
+		}
+		return chunk
+	})
+	defer srv.Close()
+	c := NewClient(srv.URL)
+	c.SetRetryPolicy(NoRetry)
+
+	buf := &bytes.Buffer{}
+	n, err := c.StreamChunkData(id, buf, 4)
+	assert.ErrorIs(t, err, ErrInvalidChunk)
+	assert.Equal(t, int64(2*types.MAX_CHUNK_SIZE), n)
+
+	dir := t.TempDir()
+	c.SetTempDir(dir)
+	_, err = c.DownloadChunkDataStream(id)
+	assert.Error(t, err)
+	entries, _ := os.ReadDir(dir)
+	assert.Equal(t, 0, len(entries))
+}
@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/everFinance/goar/utils"
 	tcrsa "github.com/everFinance/ttcrsa"
 )
 
@@ -27,10 +28,10 @@ func CreateTcKeyPair(bitSize, k, l int) (shares tcrsa.KeyShareList, meta *tcrsa.
 	now := time.Now()
 	keyShares, keyMeta, err := tcrsa.NewKey(bitSize, uint16(k), uint16(l), nil)
 	if err != nil {
-		log.Error("tcrsa newKey", "err", err, "bitSize", bitSize, "k", k, "l", l)
+		utils.DefaultLogger().Error("tcrsa newKey", "err", err, "bitSize", bitSize, "k", k, "l", l)
 		return nil, nil, err
 	}
-	log.Debug("Create rsa threshold keyPair success", "bitSize", bitSize, "spendTime", time.Since(now).String())
+	utils.DefaultLogger().Debug("Create rsa threshold keyPair success", "bitSize", bitSize, "spendTime", time.Since(now).String())
 	return keyShares, keyMeta, nil
 }
 
@@ -80,20 +81,20 @@ func (ts *TcSign) AssembleSigShares(signedShares tcrsa.SigShareList) ([]byte, er
 	// verify each signer share
 	for _, sd := range signedShares {
 		if err := sd.Verify(ts.pssData, ts.keyMeta); err != nil {
-			log.Error("verify signer sign failed", "err", err, "signer", sd.Id)
+			utils.DefaultLogger().Error("verify signer sign failed", "err", err, "signer", sd.Id)
 			return nil, err
 		}
 	}
 	signature, err := signedShares.Join(ts.pssData, ts.keyMeta)
 	if err != nil {
-		log.Error("signedShares.Join(signDataByPss, meta)", "err", err)
+		utils.DefaultLogger().Error("signedShares.Join(signDataByPss, meta)", "err", err)
 		return nil, err
 	}
 
 	// verify
 	signHashed := sha256.Sum256(ts.signData)
 	if err := rsa.VerifyPSS(ts.keyMeta.PublicKey, crypto.SHA256, signHashed[:], signature, nil); err != nil {
-		log.Error("verify signature", "err", err)
+		utils.DefaultLogger().Error("verify signature", "err", err)
 		return nil, err
 	}
 	return signature, nil
@@ -27,6 +27,50 @@ type Block struct {
 	ScheduledUsdToArRate     []string      `json:"scheduled_usd_to_ar_rate"`
 	Packing25Threshold       string        `json:"packing_2_5_threshold"`
 	StrictDataSplitThreshold string        `json:"strict_data_split_threshold"`
+
+	// 2.6
+	HashPreimage                  string            `json:"hash_preimage,omitempty"`
+	RecallByte                    interface{}       `json:"recall_byte,omitempty"` // always string
+	Reward                        interface{}       `json:"reward,omitempty"`      // always string
+	PreviousSolutionHash          string            `json:"previous_solution_hash,omitempty"`
+	PartitionNumber               interface{}       `json:"partition_number,omitempty"` // always string
+	NonceLimiterInfo              *NonceLimiterInfo `json:"nonce_limiter_info,omitempty"`
+	Poa2                          *POA              `json:"poa2,omitempty"`
+	Signature                     string            `json:"signature,omitempty"`
+	RewardKey                     string            `json:"reward_key,omitempty"`
+	PricePerGibMinute             interface{}       `json:"price_per_gib_minute,omitempty"`           // always string
+	ScheduledPricePerGibMinute    interface{}       `json:"scheduled_price_per_gib_minute,omitempty"` // always string
+	RewardHistoryHash             string            `json:"reward_history_hash,omitempty"`
+	DebtSupply                    interface{}       `json:"debt_supply,omitempty"`                       // always string
+	KryderPlusRateMultiplier      interface{}       `json:"kryder_plus_rate_multiplier,omitempty"`       // always string
+	KryderPlusRateMultiplierLatch interface{}       `json:"kryder_plus_rate_multiplier_latch,omitempty"` // always string
+	Denomination                  interface{}       `json:"denomination,omitempty"`                      // always string
+	RedenominationHeight          interface{}       `json:"redenomination_height,omitempty"`             // always string
+	DoubleSigningProof            interface{}       `json:"double_signing_proof,omitempty"`
+	PreviousCumulativeDiff        interface{}       `json:"previous_cumulative_diff,omitempty"` // always string
+	RecallByte2                   interface{}       `json:"recall_byte2,omitempty"`             // always string, only set for two-chunk solutions
+
+	// 2.7
+	MerkleRebaseSupportThreshold interface{} `json:"merkle_rebase_support_threshold,omitempty"` // always string
+	ChunkHash                    string      `json:"chunk_hash,omitempty"`
+	Chunk2Hash                   string      `json:"chunk2_hash,omitempty"`
+	BlockTimeHistoryHash         string      `json:"block_time_history_hash,omitempty"`
+}
+
+// NonceLimiterInfo is the VDF state of a 2.6+ block
+type NonceLimiterInfo struct {
+	Output              string      `json:"output"`
+	GlobalStepNumber    interface{} `json:"global_step_number"` // always string
+	Seed                string      `json:"seed"`
+	NextSeed            string      `json:"next_seed"`
+	ZoneUpperBound      interface{} `json:"zone_upper_bound"`      // always string
+	NextZoneUpperBound  interface{} `json:"next_zone_upper_bound"` // always string
+	PrevOutput          string      `json:"prev_output"`
+	LastStepCheckpoints []string    `json:"last_step_checkpoints"`
+	Checkpoints         []string    `json:"checkpoints"`
+	// 2.7
+	VdfDifficulty     interface{} `json:"vdf_difficulty,omitempty"`      // always string
+	NextVdfDifficulty interface{} `json:"next_vdf_difficulty,omitempty"` // always string
 }
 
 type POA struct {
@@ -35,3 +79,32 @@ type POA struct {
 	DataPath string `json:"data_path"`
 	Chunk    string `json:"chunk"`
 }
+
+// block chain verification checks
+const (
+	CheckIndepHash = "indep_hash"     // the indep_hash does not match the recomputed one, or the header is malformed
+	CheckPrevious  = "previous_block" // the previous_block is not the indep_hash of the previous block
+	CheckHeight    = "height"         // the height does not follow the height of the previous block
+	CheckHashList  = "hash_list"      // the indep_hash does not match the hash list
+)
+
+type BlockIssue struct {
+	Height   int64  `json:"height"`
+	Check    string `json:"check"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+}
+
+// ChainReport is the result of the verification of a range of blocks
+type ChainReport struct {
+	From       int64        `json:"from"`
+	To         int64        `json:"to"`
+	Verified   int          `json:"verified"`   // number of blocks passing all the checks
+	Unverified []int64      `json:"unverified"` // heights whose indep_hash can not be recomputed
+	Issues     []BlockIssue `json:"issues"`
+}
+
+// Valid reports whether no check failed, the indep_hash of the unverified blocks may still be wrong
+func (r *ChainReport) Valid() bool {
+	return len(r.Issues) == 0
+}
@@ -24,6 +24,11 @@ const (
 	// Maximum amount of chunks we will upload in the body.
 	MAX_CHUNKS_IN_BODY = 1
 
+	// Maximum size of the names and values of the tags of a tx
+	MAX_TAGS_SIZE = 2048
+	// Maximum size of the data of a format 1 tx, which is stored in the header
+	MAX_TX_DATA_SIZE_V1 = 10 * 1024 * 1024
+
 	// We assume these errors are intermitment and we can try again after a delay:
 	// - not_joined
 	// - timeout
@@ -0,0 +1,214 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graphql sort orders
+const (
+	SortHeightDesc = "HEIGHT_DESC"
+	SortHeightAsc  = "HEIGHT_ASC"
+)
+
+// tag filter ops and match modes, match modes other than EXACT are not supported by every gateway
+const (
+	TagOpEq  = "EQ"
+	TagOpNeq = "NEQ"
+
+	TagMatchExact    = "EXACT"
+	TagMatchWildcard = "WILDCARD"
+	TagMatchFuzzyAnd = "FUZZY_AND"
+	TagMatchFuzzyOr  = "FUZZY_OR"
+)
+
+type TagFilter struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+	Op     string   `json:"op,omitempty"`    // TagOpEq by default
+	Match  string   `json:"match,omitempty"` // TagMatchExact by default
+}
+
+// HeightRange is an inclusive block height range, nil bounds are open
+type HeightRange struct {
+	Min *int64 `json:"min,omitempty"`
+	Max *int64 `json:"max,omitempty"`
+}
+
+// TransactionQuery filters the graphql `transactions` query, empty filters are ignored
+type TransactionQuery struct {
+	Ids        []string
+	Owners     []string
+	Recipients []string
+	Tags       []TagFilter
+	BundledIn  []string
+	Block      *HeightRange
+	Sort       string // SortHeightDesc by default
+	First      int    // page size, the gateway default if 0
+}
+
+// BlockQuery filters the graphql `blocks` query, empty filters are ignored
+type BlockQuery struct {
+	Ids    []string
+	Height *HeightRange
+	Sort   string
+	First  int
+}
+
+const gqlTransactionFields = `id anchor signature recipient
+owner { address key }
+fee { winston ar }
+quantity { winston ar }
+data { size type }
+tags { name value }
+block { id timestamp height previous }
+bundledIn { id }`
+
+const gqlBlockFields = `id timestamp height previous`
+
+// Build returns the graphql query and its variables for the page after cursor
+func (q TransactionQuery) Build(after string) (string, map[string]interface{}) {
+	a := newGqlArgs()
+	if len(q.Ids) > 0 {
+		a.add("ids", "[ID!]", q.Ids)
+	}
+	if len(q.Owners) > 0 {
+		a.add("owners", "[String!]", q.Owners)
+	}
+	if len(q.Recipients) > 0 {
+		a.add("recipients", "[String!]", q.Recipients)
+	}
+	if len(q.Tags) > 0 {
+		a.add("tags", "[TagFilter!]", q.Tags)
+	}
+	if len(q.BundledIn) > 0 {
+		a.add("bundledIn", "[ID!]", q.BundledIn)
+	}
+	if q.Block != nil {
+		a.add("block", "BlockFilter", q.Block)
+	}
+	if q.Sort != "" {
+		a.add("sort", "SortOrder", q.Sort)
+	}
+	if q.First > 0 {
+		a.add("first", "Int", q.First)
+	}
+	if after != "" {
+		a.add("after", "String", after)
+	}
+	return a.query("transactions", gqlTransactionFields), a.vars
+}
+
+// Build returns the graphql query and its variables for the page after cursor
+func (q BlockQuery) Build(after string) (string, map[string]interface{}) {
+	a := newGqlArgs()
+	if len(q.Ids) > 0 {
+		a.add("ids", "[ID!]", q.Ids)
+	}
+	if q.Height != nil {
+		a.add("height", "BlockFilter", q.Height)
+	}
+	if q.Sort != "" {
+		a.add("sort", "SortOrder", q.Sort)
+	}
+	if q.First > 0 {
+		a.add("first", "Int", q.First)
+	}
+	if after != "" {
+		a.add("after", "String", after)
+	}
+	return a.query("blocks", gqlBlockFields), a.vars
+}
+
+// gqlArgs collects the arguments of a query, passed as variables
+type gqlArgs struct {
+	params []string
+	args   []string
+	vars   map[string]interface{}
+}
+
+func newGqlArgs() *gqlArgs {
+	return &gqlArgs{vars: map[string]interface{}{}}
+}
+
+func (a *gqlArgs) add(name, typ string, value interface{}) {
+	a.vars[name] = value
+	a.params = append(a.params, fmt.Sprintf("$%s: %s", name, typ))
+	a.args = append(a.args, fmt.Sprintf("%s: $%s", name, name))
+}
+
+func (a *gqlArgs) query(name string, fields string) string {
+	query := "query"
+	if len(a.params) > 0 {
+		query += "(" + strings.Join(a.params, ", ") + ")"
+	}
+	query += " { " + name
+	if len(a.args) > 0 {
+		query += "(" + strings.Join(a.args, ", ") + ")"
+	}
+	return query + " { pageInfo { hasNextPage } edges { cursor node { " + fields + " } } } }"
+}
+
+type GQLAmount struct {
+	Winston string `json:"winston"`
+	Ar      string `json:"ar"`
+}
+
+type GQLOwner struct {
+	Address string `json:"address"`
+	Key     string `json:"key"`
+}
+
+type GQLData struct {
+	Size string `json:"size"`
+	Type string `json:"type"`
+}
+
+type GQLBlock struct {
+	Id        string `json:"id"`
+	Timestamp int64  `json:"timestamp"`
+	Height    int64  `json:"height"`
+	Previous  string `json:"previous"`
+}
+
+type GQLBundle struct {
+	Id string `json:"id"`
+}
+
+type GQLTransaction struct {
+	Id        string     `json:"id"`
+	Anchor    string     `json:"anchor"`
+	Signature string     `json:"signature"`
+	Recipient string     `json:"recipient"`
+	Owner     GQLOwner   `json:"owner"`
+	Fee       GQLAmount  `json:"fee"`
+	Quantity  GQLAmount  `json:"quantity"`
+	Data      GQLData    `json:"data"`
+	Tags      []Tag      `json:"tags"`
+	Block     *GQLBlock  `json:"block"`     // nil while pending
+	BundledIn *GQLBundle `json:"bundledIn"` // nil if the tx is not a bundle item
+}
+
+type GQLPageInfo struct {
+	HasNextPage bool `json:"hasNextPage"`
+}
+
+type GQLTransactionEdge struct {
+	Cursor string         `json:"cursor"`
+	Node   GQLTransaction `json:"node"`
+}
+
+type GQLTransactions struct {
+	PageInfo GQLPageInfo          `json:"pageInfo"`
+	Edges    []GQLTransactionEdge `json:"edges"`
+}
+
+type GQLBlockEdge struct {
+	Cursor string   `json:"cursor"`
+	Node   GQLBlock `json:"node"`
+}
+
+type GQLBlocks struct {
+	PageInfo GQLPageInfo    `json:"pageInfo"`
+	Edges    []GQLBlockEdge `json:"edges"`
+}
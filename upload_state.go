@@ -0,0 +1,212 @@
+package goar
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+)
+
+// UploadStateVersion is the version of the UploadState format written by this package
+const UploadStateVersion = 1
+
+// UploadState is the durable state of an upload. It can be saved after every chunk, see TransactionUploader.StateFile,
+// and passed to CreateUploader with the same data to resume the upload after a crash.
+type UploadState struct {
+	Version     int                `json:"version"`
+	Transaction *types.Transaction `json:"transaction"` // signed header, without the data
+	TxPosted    bool               `json:"txPosted"`
+	TotalChunks int                `json:"totalChunks"`
+	// ConfirmedChunks is a bitmap of the chunks acknowledged by the gateway, bit i (i%8 of byte i/8) is chunk i, base64url encoded
+	ConfirmedChunks    string `json:"confirmedChunks"`
+	LastRequestTimeEnd int64  `json:"lastRequestTimeEnd"`
+	LastResponseStatus int    `json:"lastResponseStatus"`
+	LastResponseError  string `json:"lastResponseError"`
+	// DataPath is the file the data was read from, empty for data in memory
+	DataPath string `json:"dataPath,omitempty"`
+}
+
+// LoadUploadState reads a state saved by SaveUploadState
+func LoadUploadState(path string) (*UploadState, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &UploadState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, fmt.Errorf("invalid upload state %s: %w", path, err)
+	}
+	if state.Version != UploadStateVersion {
+		return nil, fmt.Errorf("unsupported upload state version %d", state.Version)
+	}
+	return state, nil
+}
+
+// SaveUploadState writes the state to path atomically, a crash leaves either the previous or the new state
+func SaveUploadState(path string, state *UploadState) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// State returns the current state of the upload
+func (tt *TransactionUploader) State() *UploadState {
+	tt.stateLock.Lock()
+	defer tt.stateLock.Unlock()
+	state := &UploadState{
+		Version:            UploadStateVersion,
+		Transaction:        tt.Transaction,
+		TxPosted:           tt.TxPosted,
+		TotalChunks:        tt.TotalChunks(),
+		ConfirmedChunks:    encodeChunkBitmap(tt.confirmed),
+		LastRequestTimeEnd: tt.LastRequestTimeEnd,
+		LastResponseStatus: tt.LastResponseStatus,
+		LastResponseError:  tt.LastResponseError,
+	}
+	if tt.DataReader != nil {
+		state.DataPath = tt.DataReader.Name()
+	}
+	return state
+}
+
+// saveState writes the state to StateFile, if set
+func (tt *TransactionUploader) saveState() {
+	if tt.StateFile == "" {
+		return
+	}
+	tt.saveLock.Lock()
+	defer tt.saveLock.Unlock()
+	if err := SaveUploadState(tt.StateFile, tt.State()); err != nil {
+		tt.log().Warn("save upload state failed", "path", tt.StateFile, "err", err)
+	}
+}
+
+// confirmChunk records that chunk idx was acknowledged by the gateway
+func (tt *TransactionUploader) confirmChunk(idx int) {
+	tt.stateLock.Lock()
+	if tt.confirmed == nil {
+		tt.confirmed = make([]bool, tt.TotalChunks())
+	}
+	tt.confirmed[idx] = true
+	tt.stateLock.Unlock()
+}
+
+// isConfirmed reports whether chunk idx was acknowledged by the gateway
+func (tt *TransactionUploader) isConfirmed(idx int) bool {
+	tt.stateLock.Lock()
+	defer tt.stateLock.Unlock()
+	return idx < len(tt.confirmed) && tt.confirmed[idx]
+}
+
+// nextChunk moves ChunkIndex to the first chunk not acknowledged yet
+func (tt *TransactionUploader) nextChunk() {
+	for tt.ChunkIndex < tt.TotalChunks() && tt.isConfirmed(tt.ChunkIndex) {
+		tt.ChunkIndex++
+	}
+}
+
+// FromState reconstructs an upload from its state and data, []byte or *os.File.
+// The chunks are prepared again from the data, which must match the data_root of the transaction.
+func (tt *TransactionUploader) FromState(state *UploadState, data interface{}) (*TransactionUploader, error) {
+	if state == nil || state.Transaction == nil {
+		return nil, errors.New("Serialized object does not match expected format.")
+	}
+	if state.Version != UploadStateVersion {
+		return nil, fmt.Errorf("unsupported upload state version %d", state.Version)
+	}
+
+	tx := *state.Transaction
+	tx.Chunks = nil
+	tx.DataReader = nil
+	upload, err := newUploader(&tx, tt.Client)
+	if err != nil {
+		return nil, err
+	}
+	upload.TxPosted = state.TxPosted
+	upload.LastRequestTimeEnd = state.LastRequestTimeEnd
+	upload.LastResponseStatus = state.LastResponseStatus
+	upload.LastResponseError = state.LastResponseError
+
+	dataSize := 0
+	switch d := data.(type) {
+	case []byte:
+		upload.Data = d
+		dataSize = len(d)
+	case *os.File:
+		info, err := d.Stat()
+		if err != nil {
+			return nil, err
+		}
+		upload.Data = nil
+		upload.DataReader = d
+		dataSize = int(info.Size())
+	case nil:
+		upload.Data = nil
+	default:
+		return nil, fmt.Errorf("unsupported data type %T", data)
+	}
+	if err := utils.PrepareChunks(upload.Transaction, data, dataSize); err != nil {
+		return nil, err
+	}
+	if upload.Transaction.DataRoot != state.Transaction.DataRoot {
+		return nil, errors.New("Data mismatch: Uploader doesn't match provided Data.")
+	}
+
+	// a state without chunks, eg: from FromTransactionId, starts from the first chunk
+	if (state.TotalChunks != 0 || state.ConfirmedChunks != "") && upload.TotalChunks() != state.TotalChunks {
+		return nil, fmt.Errorf("Data mismatch: %d chunks, the upload state has %d", upload.TotalChunks(), state.TotalChunks)
+	}
+	upload.confirmed, err = decodeChunkBitmap(state.ConfirmedChunks, upload.TotalChunks())
+	if err != nil {
+		return nil, err
+	}
+	upload.nextChunk()
+	return upload, nil
+}
+
+func encodeChunkBitmap(confirmed []bool) string {
+	bitmap := make([]byte, (len(confirmed)+7)/8)
+	for i, ok := range confirmed {
+		if ok {
+			bitmap[i/8] |= 1 << (i % 8)
+		}
+	}
+	return utils.Base64Encode(bitmap)
+}
+
+func decodeChunkBitmap(s string, totalChunks int) ([]bool, error) {
+	bitmap, err := utils.Base64Decode(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid chunk bitmap: %w", err)
+	}
+	if len(bitmap) > (totalChunks+7)/8 {
+		return nil, fmt.Errorf("invalid chunk bitmap: %d bytes for %d chunks", len(bitmap), totalChunks)
+	}
+	confirmed := make([]bool, totalChunks)
+	for i := range confirmed {
+		confirmed[i] = i/8 < len(bitmap) && bitmap[i/8]&(1<<(i%8)) != 0
+	}
+	return confirmed, nil
+}
@@ -0,0 +1,197 @@
+package goar
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/everFinance/goar/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransactionUploader_ResumeFromState(t *testing.T) {
+	data := make([]byte, 4*types.MAX_CHUNK_SIZE+100)
+	rand.Read(data)
+	dataPath := filepath.Join(t.TempDir(), "data")
+	assert.NoError(t, os.WriteFile(dataPath, data, 0600))
+	f, err := os.Open(dataPath)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	tx := &types.Transaction{Format: 2, ID: "mock-tx", DataSize: strconv.Itoa(len(data)), DataReader: f}
+	assert.NoError(t, utils.PrepareChunks(tx, f, len(data)))
+
+	var (
+		lock     sync.Mutex
+		txPosts  int
+		offsets  []string
+		crashing = true
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path == "/tx" {
+			txPosts++
+			w.Write([]byte("OK"))
+			return
+		}
+		gc := &types.GetChunk{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(gc))
+		// the third chunk fails, like a process killed during the upload
+		if crashing && len(offsets) == 2 {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_proof"}`))
+			return
+		}
+		offsets = append(offsets, gc.Offset)
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	statePath := filepath.Join(t.TempDir(), "upload.json")
+	uploader, err := CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	uploader.StateFile = statePath
+	assert.Error(t, uploader.Once())
+
+	state, err := LoadUploadState(statePath)
+	assert.NoError(t, err)
+	assert.Equal(t, UploadStateVersion, state.Version)
+	assert.True(t, state.TxPosted)
+	assert.Equal(t, 5, state.TotalChunks)
+	assert.Equal(t, dataPath, state.DataPath)
+	assert.Equal(t, "", state.Transaction.Data)
+
+	// the data must match the data_root of the state
+	_, err = CreateUploader(c, state, make([]byte, len(data)))
+	assert.Error(t, err)
+
+	crashing = false
+	resumed, err := CreateUploader(c, state, f)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, resumed.ChunkIndex)
+	assert.NoError(t, resumed.Once())
+	assert.True(t, resumed.IsComplete())
+	assert.Equal(t, 1, txPosts)
+	assert.Equal(t, 5, len(offsets))
+	for i, offset := range offsets {
+		assert.Equal(t, strconv.Itoa(tx.Chunks.Chunks[i].MaxByteRange-1), offset, i)
+	}
+}
+
+func TestChunkBitmap(t *testing.T) {
+	confirmed := []bool{true, false, true, false, false, false, false, false, true}
+	s := encodeChunkBitmap(confirmed)
+	decoded, err := decodeChunkBitmap(s, len(confirmed))
+	assert.NoError(t, err)
+	assert.Equal(t, confirmed, decoded)
+
+	_, err = decodeChunkBitmap(s, 8)
+	assert.Error(t, err)
+}
+
+func TestTransactionUploader_ConcurrentOnceMissingChunks(t *testing.T) {
+	data := make([]byte, 5*types.MAX_CHUNK_SIZE+100)
+	rand.Read(data)
+	tx := &types.Transaction{Format: 2, ID: "mock-tx", DataSize: strconv.Itoa(len(data)), Data: utils.Base64Encode(data)}
+	assert.NoError(t, utils.PrepareChunks(tx, data, len(data)))
+	offset := func(idx int) string {
+		return strconv.Itoa(tx.Chunks.Chunks[idx].MaxByteRange - 1)
+	}
+
+	var (
+		lock    sync.Mutex
+		txPosts int
+		posted  []string
+		failing = map[string]string{offset(1): `{"error":"unknown"}`}
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if r.URL.Path == "/tx" {
+			txPosts++
+			w.Write([]byte("OK"))
+			return
+		}
+		gc := &types.GetChunk{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(gc))
+		posted = append(posted, gc.Offset)
+		if body, ok := failing[gc.Offset]; ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(body))
+			return
+		}
+		w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	c := NewClient(srv.URL)
+
+	uploader, err := CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	uploader.StateFile = filepath.Join(t.TempDir(), "upload.json")
+	err = uploader.ConcurrentOnce(context.Background(), 2)
+	var chunkErr *ChunkUploadError
+	assert.True(t, errors.As(err, &chunkErr))
+	assert.Equal(t, []int{1}, mapKeys(chunkErr.Failed))
+	assert.Empty(t, chunkErr.Skipped)
+	assert.NoError(t, chunkErr.Err)
+	assert.Equal(t, 1, uploader.ChunkIndex)
+	assert.False(t, uploader.IsComplete())
+	state, err := LoadUploadState(uploader.StateFile)
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, false, true, true, true, true}, mustDecodeChunkBitmap(t, state))
+
+	// only the missing chunk is sent again
+	delete(failing, offset(1))
+	posted = nil
+	assert.NoError(t, uploader.ConcurrentOnce(context.Background(), 2))
+	assert.True(t, uploader.IsComplete())
+	assert.Equal(t, []string{offset(1)}, posted)
+	assert.Equal(t, 1, txPosts)
+
+	// a fatal error stops the upload, a single worker sends the chunks in order
+	failing[offset(2)] = `{"error":"disk_full"}`
+	posted = nil
+	uploader, err = CreateUploader(c, tx, nil)
+	assert.NoError(t, err)
+	err = uploader.ConcurrentOnce(context.Background(), 1)
+	assert.True(t, errors.As(err, &chunkErr))
+	assert.Equal(t, []int{2}, mapKeys(chunkErr.Failed))
+	assert.Equal(t, []int{3, 4, 5}, chunkErr.Skipped)
+	assert.ErrorContains(t, err, "disk_full")
+	assert.Equal(t, []string{offset(0), offset(1), offset(2)}, posted)
+
+	// a cancelled context skips everything left
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = uploader.ConcurrentOnce(ctx, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.True(t, errors.As(err, &chunkErr))
+	assert.Equal(t, []int{2, 3, 4, 5}, chunkErr.Skipped)
+}
+
+func mapKeys(m map[int]error) []int {
+	keys := make([]int, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
+
+func mustDecodeChunkBitmap(t *testing.T, state *UploadState) []bool {
+	confirmed, err := decodeChunkBitmap(state.ConfirmedChunks, state.TotalChunks)
+	assert.NoError(t, err)
+	return confirmed
+}
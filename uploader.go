@@ -6,8 +6,8 @@ import (
 	"fmt"
 	"github.com/panjf2000/ants/v2"
 	"math"
-	"math/rand"
 	"os"
+	"sort"
 	"strconv"
 	"sync"
 	"time"
@@ -37,6 +37,18 @@ type TransactionUploader struct {
 	TotalErrors        int // Not serialized.
 	LastResponseStatus int
 	LastResponseError  string
+	// RetryPolicy controls how failed chunk and tx submissions are retried, DefaultUploadRetryPolicy if zero
+	RetryPolicy RetryPolicy `json:"-"`
+	// Progress receives the progress of the upload, the progress callback of the Client if nil
+	Progress ProgressFunc `json:"-"`
+	// StateFile, optional, is where the UploadState is saved after the tx and every chunk are posted
+	StateFile string `json:"-"`
+
+	lastRetryAfter time.Duration
+	progress       *progressTracker
+	stateLock      sync.Mutex
+	saveLock       sync.Mutex // orders the saves of concurrent chunks
+	confirmed      []bool     // chunks acknowledged by the gateway
 }
 
 func newUploader(tt *types.Transaction, client *Client) (*TransactionUploader, error) {
@@ -44,16 +56,17 @@ func newUploader(tt *types.Transaction, client *Client) (*TransactionUploader, e
 		return nil, errors.New("Transaction is not signed.")
 	}
 	if tt.Chunks == nil {
-		log.Warn("Transaction chunks not perpared")
+		client.log().Warn("Transaction chunks not perpared")
 	}
 	// Make a copy of Transaction, zeroing the Data so we can serialize.
 	tu := &TransactionUploader{
-		Client: client,
+		Client:      client,
+		RetryPolicy: DefaultUploadRetryPolicy(),
 	}
 	// empty data is fine
 	da, err := utils.Base64Decode(tt.Data)
 	if err != nil {
-		log.Error("utils.Base64Decode(tt.Data)", "err", err)
+		client.log().Error("utils.Base64Decode(tt.Data)", "err", err)
 		return nil, err
 
 	}
@@ -80,9 +93,9 @@ func newUploader(tt *types.Transaction, client *Client) (*TransactionUploader, e
 }
 
 // CreateUploader
-// @param upload: Transaction | SerializedUploader | string,
-// @param Data the Data of the Transaction. Required when resuming an upload.
-func CreateUploader(api *Client, upload interface{}, data []byte) (*TransactionUploader, error) {
+// @param upload: Transaction | UploadState | SerializedUploader | string,
+// @param Data the Data of the Transaction, []byte or *os.File. Required when resuming an upload.
+func CreateUploader(api *Client, upload interface{}, data interface{}) (*TransactionUploader, error) {
 	var (
 		uploader *TransactionUploader
 		err      error
@@ -96,11 +109,15 @@ func CreateUploader(api *Client, upload interface{}, data []byte) (*TransactionU
 		return uploader, nil
 	}
 
+	if state, ok := upload.(*UploadState); ok {
+		return (&TransactionUploader{Client: api}).FromState(state, data)
+	}
+
 	if id, ok := upload.(string); ok {
 		// upload 返回为 SerializedUploader 类型
 		upload, err = (&TransactionUploader{Client: api}).FromTransactionId(id)
 		if err != nil {
-			log.Error("(&TransactionUploader{Client: api}).FromTransactionId(id)", "err", err)
+			api.log().Error("(&TransactionUploader{Client: api}).FromTransactionId(id)", "err", err)
 			return nil, err
 		}
 	} else {
@@ -116,6 +133,10 @@ func CreateUploader(api *Client, upload interface{}, data []byte) (*TransactionU
 	return uploader, err
 }
 
+func (tt *TransactionUploader) log() Logger {
+	return tt.Client.log().With("arId", tt.Transaction.ID)
+}
+
 func (tt *TransactionUploader) Once() (err error) {
 	for !tt.IsComplete() {
 		if err = tt.UploadChunk(); err != nil {
@@ -157,16 +178,84 @@ func (tt *TransactionUploader) PctComplete() float64 {
 	return math.Trunc(fval * 100)
 }
 
+func (tt *TransactionUploader) newProgress() *progressTracker {
+	fn := tt.Progress
+	if fn == nil && tt.Client != nil {
+		fn = tt.Client.progress
+	}
+	size, _ := strconv.ParseInt(tt.Transaction.DataSize, 10, 64)
+	return newProgressTracker(fn, OpUpload, tt.Transaction.ID, size, tt.TotalChunks())
+}
+
+func (tt *TransactionUploader) chunkHook(client *Client, idx, attempt int, duration time.Duration, resp *response) {
+	var err error
+	if !resp.ok(200) {
+		err = resp.apiError(nil)
+	}
+	client.chunkHook(ChunkEvent{
+		Op:       OpUpload,
+		TxId:     tt.Transaction.ID,
+		Offset:   int64(tt.Transaction.Chunks.Chunks[idx].MinByteRange),
+		Size:     tt.chunkSize(idx),
+		Attempt:  attempt,
+		Duration: duration,
+		Err:      err,
+	})
+}
+
+func (tt *TransactionUploader) chunkSize(idx int) int {
+	chunk := tt.Transaction.Chunks.Chunks[idx]
+	return chunk.MaxByteRange - chunk.MinByteRange
+}
+
+// ConcurrentOnce posts the transaction, if not posted yet, and uploads the chunks not acknowledged yet with concurrentNum workers.
+// Every acknowledged chunk is recorded, and saved to StateFile if set, so a following call only uploads the missing chunks.
+// When some chunks are not acknowledged it returns a *ChunkUploadError. A fatal chunk error, see IsFatalChunkError,
+// stops the upload and the chunks not sent yet are skipped, like when ctx is done.
 func (tt *TransactionUploader) ConcurrentOnce(ctx context.Context, concurrentNum int) error {
+	client := tt.Client.WithContext(ctx)
 	// post tx info
-	if err := tt.postTransaction(); err != nil {
-		return err
+	if !tt.TxPosted {
+		if err := tt.postTransaction(client); err != nil {
+			return err
+		}
 	}
 
 	if tt.IsComplete() {
 		return nil
 	}
 
+	// a fatal chunk error cancels the chunks not sent yet
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// chunks are retried here so that a failure is not retried twice by the client
+	policy := tt.RetryPolicy.orDefault(DefaultUploadRetryPolicy())
+	chunkClient := tt.Client.WithContext(runCtx).withRetryPolicy(NoRetry)
+	progress := tt.newProgress()
+	resumed := int64(0)
+	for idx := 0; idx < tt.TotalChunks(); idx++ {
+		if tt.isConfirmed(idx) {
+			resumed += int64(tt.chunkSize(idx))
+		}
+	}
+	progress.resumeAt(resumed)
+
+	var (
+		lock     sync.Mutex
+		failed   = make(map[int]error)
+		skipped  []int
+		fatalErr error
+	)
+	fail := func(idx int, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if runCtx.Err() != nil && err == runCtx.Err() {
+			skipped = append(skipped, idx)
+			return
+		}
+		failed[idx] = err
+	}
+
 	var wg sync.WaitGroup
 	if concurrentNum <= 0 {
 		concurrentNum = types.DEFAULT_CHUNK_CONCURRENT_NUM
@@ -176,11 +265,10 @@ func (tt *TransactionUploader) ConcurrentOnce(ctx context.Context, concurrentNum
 		// process submit chunk
 		idx := i.(int)
 
-		select {
-		case <-ctx.Done():
-			log.Warn("ctx.done", "chunkIdx", idx)
+		if err := runCtx.Err(); err != nil {
+			tt.log().Warn("ctx.done", "chunkIdx", idx)
+			fail(idx, err)
 			return
-		default:
 		}
 		var chunk *types.GetChunk
 		var err error
@@ -190,51 +278,96 @@ func (tt *TransactionUploader) ConcurrentOnce(ctx context.Context, concurrentNum
 			chunk, err = utils.GetChunk(*tt.Transaction, idx, tt.Data)
 		}
 		if err != nil {
-			log.Error("GetChunk error", "err", err, "idx", idx)
+			tt.log().Error("GetChunk error", "err", err, "idx", idx)
+			fail(idx, err)
 			return
 		}
-		body, statusCode, err := tt.Client.SubmitChunks(chunk) // always body is errMsg
-		if statusCode == 200 {
-			return
-		}
-
-		log.Error("concurrent submitChunk failed", "chunkIdx", idx, "statusCode", statusCode, "gatewayErr", body, "httpErr", err)
-		// try again
-		retryCount := 0
-		for {
-			select {
-			case <-ctx.Done():
-				log.Warn("ctx.done", "chunkIdx", idx)
+		for attempt := 1; ; attempt++ {
+			start := time.Now()
+			resp, err := chunkClient.submitChunks(chunk)
+			if err != nil {
+				tt.log().Error("marshal chunk failed", "err", err, "chunkIdx", idx)
+				fail(idx, err)
 				return
-			default:
 			}
-
-			retryCount++
-			if statusCode == 429 {
-				time.Sleep(1 * time.Second)
-			} else {
-				time.Sleep(200 * time.Millisecond)
+			tt.chunkHook(client, idx, attempt, time.Since(start), resp)
+			if resp.ok(200) {
+				progress.chunkDone(idx, tt.chunkSize(idx))
+				tt.confirmChunk(idx)
+				tt.saveState()
+				return
 			}
-
-			body, statusCode, err = tt.Client.SubmitChunks(chunk)
-			if statusCode == 200 {
+			if err := runCtx.Err(); err != nil {
+				tt.log().Warn("ctx.done", "chunkIdx", idx)
+				fail(idx, err)
+				return
+			}
+			apiErr := resp.apiError(nil) // always body is errMsg
+			if IsFatalChunkError(resp.body) {
+				tt.log().Error("fatal submitChunk error, stop the upload", "chunkIdx", idx, "err", apiErr)
+				progress.chunkFailed(idx, attempt, apiErr)
+				lock.Lock()
+				if fatalErr == nil {
+					fatalErr = fmt.Errorf("Fatal error uploading chunk %d: %w", idx, apiErr)
+				}
+				lock.Unlock()
+				fail(idx, apiErr)
+				cancel()
+				return
+			}
+			if !policy.ShouldRetry(resp.statusCode, resp.body, resp.err) || attempt >= policy.MaxAttempts {
+				tt.log().Error("concurrent submitChunk failed", "chunkIdx", idx, "attempt", attempt, "err", apiErr)
+				progress.chunkFailed(idx, attempt, apiErr)
+				fail(idx, apiErr)
+				return
+			}
+			tt.log().Warn("retry submitChunk failed", "retryCount", attempt, "chunkIdx", idx, "err", apiErr)
+			progress.chunkRetry(idx, attempt, apiErr)
+			if err := chunkClient.sleep(policy.Backoff(attempt, apiErr.RetryAfter)); err != nil {
+				tt.log().Warn("ctx.done", "chunkIdx", idx)
+				fail(idx, err)
 				return
 			}
-			log.Warn("retry submitChunk failed", "retryCount", retryCount, "chunkIdx", idx, "statusCode", statusCode, "gatewayErr", body, "httpErr", err)
 		}
 	})
 
 	defer p.Release()
-	for i := 0; i < len(tt.Transaction.Chunks.Chunks); i++ {
+	var invokeErr error
+	for i := 0; i < tt.TotalChunks(); i++ {
+		if tt.isConfirmed(i) {
+			continue
+		}
+		if invokeErr != nil {
+			lock.Lock()
+			skipped = append(skipped, i)
+			lock.Unlock()
+			continue
+		}
 		wg.Add(1)
 		if err := p.Invoke(i); err != nil {
-			log.Error("p.Invoke(i)", "err", err, "i", i)
-			return err
+			tt.log().Error("p.Invoke(i)", "err", err, "i", i)
+			wg.Done()
+			invokeErr = err
+			lock.Lock()
+			skipped = append(skipped, i)
+			lock.Unlock()
 		}
 	}
 
 	wg.Wait()
-	return nil
+	tt.nextChunk()
+	if len(failed) == 0 && len(skipped) == 0 {
+		return nil
+	}
+	sort.Ints(skipped)
+	cause := fatalErr
+	if cause == nil {
+		cause = ctx.Err()
+	}
+	if cause == nil {
+		cause = invokeErr
+	}
+	return &ChunkUploadError{TxId: tt.Transaction.ID, Failed: failed, Skipped: skipped, Err: cause}
 }
 
 /**
@@ -246,7 +379,7 @@ func (tt *TransactionUploader) ConcurrentOnce(ctx context.Context, concurrentNum
 func (tt *TransactionUploader) UploadChunk() error {
 	defer func() {
 		// if tt.TotalChunks() > 0 {
-		// 	log.Debug("chunks", "uploads", fmt.Sprintf("%f%% completes, %d/%d", tt.PctComplete(), tt.UploadedChunks(), tt.TotalChunks()))
+		// 	tt.log().Debug("chunks", "uploads", fmt.Sprintf("%f%% completes, %d/%d", tt.PctComplete(), tt.UploadedChunks(), tt.TotalChunks()))
 		// }
 	}()
 	if tt.IsComplete() {
@@ -259,26 +392,27 @@ func (tt *TransactionUploader) UploadChunk() error {
 		tt.TotalErrors = 0
 	}
 
-	// We have been trying for about an hour receiving an
+	// We have been trying for a long time receiving an
 	// error every time, so eventually bail.
-	if tt.TotalErrors == 100 {
+	policy := tt.RetryPolicy.orDefault(DefaultUploadRetryPolicy())
+	if tt.TotalErrors >= policy.MaxAttempts {
 		return errors.New(fmt.Sprintf("Unable to complete upload: %d:%s", tt.LastResponseStatus, tt.LastResponseError))
 	}
 
-	var delay = 0.0
-	if tt.LastResponseError != "" {
-		delay = math.Max(float64(tt.LastRequestTimeEnd+types.ERROR_DELAY-time.Now().UnixNano()/1000000), float64(types.ERROR_DELAY))
-	}
-	if delay > 0.0 {
-		// Jitter delay bcoz networks, subtract up to 30% from 40 seconds
-		delay = delay - delay*0.3*rand.Float64()
-		time.Sleep(time.Duration(delay) * time.Millisecond) // 休眠
+	if tt.TotalErrors > 0 {
+		elapsed := time.Duration(time.Now().UnixNano()/1000000-tt.LastRequestTimeEnd) * time.Millisecond
+		delay := policy.Backoff(tt.TotalErrors, tt.lastRetryAfter) - elapsed
+		if delay > 0 {
+			if err := tt.Client.sleep(delay); err != nil { // 休眠
+				return err
+			}
+		}
 	}
 
 	tt.LastResponseError = ""
 
 	if !tt.TxPosted {
-		return tt.postTransaction()
+		return tt.postTransaction(tt.Client)
 	}
 
 	var chunk *types.GetChunk
@@ -317,17 +451,42 @@ func (tt *TransactionUploader) UploadChunk() error {
 	if err != nil {
 		return err
 	}
-	body, statusCode, err := tt.Client.SubmitChunks(gc) // always body is errMsg
+	// chunks are retried by the uploader, the client must not retry them again
+	start := time.Now()
+	resp, err := tt.Client.withRetryPolicy(NoRetry).submitChunks(gc)
+	if err != nil {
+		return err
+	}
+	tt.chunkHook(tt.Client, tt.ChunkIndex, tt.TotalErrors+1, time.Since(start), resp)
 	tt.LastRequestTimeEnd = time.Now().UnixNano() / 1000000
-	tt.LastResponseStatus = statusCode
-	if statusCode == 200 {
-		tt.ChunkIndex++
+	tt.LastResponseStatus = resp.statusCode
+	if tt.progress == nil {
+		tt.progress = tt.newProgress()
+		if tt.ChunkIndex > 0 { // resumed upload
+			tt.progress.resumeAt(int64(tt.Transaction.Chunks.Chunks[tt.ChunkIndex-1].MaxByteRange))
+		}
+	}
+	if resp.ok(200) {
+		tt.progress.chunkDone(tt.ChunkIndex, tt.chunkSize(tt.ChunkIndex))
+		tt.confirmChunk(tt.ChunkIndex)
+		tt.nextChunk()
+		tt.lastRetryAfter = 0
+		tt.saveState()
 	} else {
-		errStr := fmt.Sprintf("%s,%v,%d", body, err, statusCode)
-		tt.LastResponseError = errStr
-		if _, ok := types.FATAL_CHUNK_UPLOAD_ERRORS[body]; ok {
-			return errors.New(fmt.Sprintf("Fatal error uploading chunk %d:%v", tt.ChunkIndex, body))
+		apiErr := resp.apiError(nil) // always body is errMsg
+		tt.LastResponseError = fmt.Sprintf("%s,%v,%d", resp.body, resp.err, resp.statusCode)
+		tt.lastRetryAfter = apiErr.RetryAfter
+		if ctxErr := tt.Client.Context().Err(); ctxErr != nil {
+			tt.progress.chunkFailed(tt.ChunkIndex, tt.TotalErrors+1, ctxErr)
+			return ctxErr
+		}
+		// only the fatal chunk errors abort the upload, the others are retried until MaxAttempts,
+		// unless the policy classifies them itself
+		if IsFatalChunkError(resp.body) || (policy.Retryable != nil && !policy.Retryable(resp.statusCode, resp.body, resp.err)) {
+			tt.progress.chunkFailed(tt.ChunkIndex, tt.TotalErrors+1, apiErr)
+			return fmt.Errorf("Fatal error uploading chunk %d: %w", tt.ChunkIndex, apiErr)
 		}
+		tt.progress.chunkRetry(tt.ChunkIndex, tt.TotalErrors+1, apiErr)
 	}
 	return nil
 }
@@ -337,37 +496,31 @@ func (tt *TransactionUploader) UploadChunk() error {
  * Checks if data matches the expected data_root.
  *
  * @param serialized
- * @param data
+ * @param data []byte or *os.File
  */
-func (tt *TransactionUploader) FromSerialized(serialized *SerializedUploader, data []byte) (*TransactionUploader, error) {
-	if serialized == nil {
+func (tt *TransactionUploader) FromSerialized(serialized *SerializedUploader, data interface{}) (*TransactionUploader, error) {
+	if serialized == nil || serialized.transaction == nil {
 		return nil, errors.New("Serialized object does not match expected format.")
 	}
-
-	// Everything looks ok, reconstruct the TransactionUpload,
-	// prepare the chunks again and verify the data_root matches
-	upload, err := newUploader(serialized.transaction, tt.Client)
-	if err != nil {
-		return nil, err
-	}
-	// Copy the serialized upload information, and Data passed in.
-	upload.ChunkIndex = serialized.chunkIndex
-	upload.LastRequestTimeEnd = serialized.lastRequestTimeEnd
-	upload.LastResponseError = serialized.lastResponseError
-	upload.LastResponseStatus = serialized.lastResponseStatus
-	upload.TxPosted = serialized.txPosted
-	upload.Data = data
-
-	err = utils.PrepareChunks(upload.Transaction, data, len(data))
-	if err != nil {
-		return nil, err
-	}
-
-	if upload.Transaction.DataRoot != serialized.transaction.DataRoot {
-		return nil, errors.New("Data mismatch: Uploader doesn't match provided Data.")
-	}
-
-	return upload, nil
+	// the chunks before chunkIndex were uploaded
+	confirmed := make([]bool, serialized.chunkIndex)
+	for i := range confirmed {
+		confirmed[i] = true
+	}
+	totalChunks := 0
+	if serialized.transaction.Chunks != nil {
+		totalChunks = len(serialized.transaction.Chunks.Chunks)
+	}
+	return tt.FromState(&UploadState{
+		Version:            UploadStateVersion,
+		Transaction:        serialized.transaction,
+		TxPosted:           serialized.txPosted,
+		TotalChunks:        totalChunks,
+		ConfirmedChunks:    encodeChunkBitmap(confirmed),
+		LastRequestTimeEnd: serialized.lastRequestTimeEnd,
+		LastResponseStatus: serialized.lastResponseStatus,
+		LastResponseError:  serialized.lastResponseError,
+	}, data)
 }
 
 /**
@@ -409,17 +562,17 @@ func (tt *TransactionUploader) FormatSerializedUploader() *SerializedUploader {
 }
 
 // POST to /tx
-func (tt *TransactionUploader) postTransaction() error {
+func (tt *TransactionUploader) postTransaction(client *Client) error {
 	var uploadInBody = tt.TotalChunks() <= types.MAX_CHUNKS_IN_BODY
-	return tt.uploadTx(uploadInBody)
+	return tt.uploadTx(client, uploadInBody)
 }
 
-func (tt *TransactionUploader) uploadTx(withBody bool) error {
+func (tt *TransactionUploader) uploadTx(client *Client, withBody bool) error {
 	// if withBody {
 	// 	// Post the Transaction with Data.
 	// 	tt.Transaction.Data = utils.Base64Encode(tt.Data)
 	// }
-	body, statusCode, err := tt.Client.SubmitTransaction(tt.Transaction)
+	body, statusCode, err := client.SubmitTransaction(tt.Transaction)
 	if err != nil || statusCode >= 400 {
 		tt.LastResponseError = fmt.Sprintf("%v,%s", err, body)
 		tt.LastResponseStatus = statusCode
@@ -436,6 +589,7 @@ func (tt *TransactionUploader) uploadTx(withBody bool) error {
 	// tx already processed
 	if statusCode >= 200 && statusCode < 300 {
 		tt.TxPosted = true
+		tt.saveState()
 		// if withBody {
 		// 	// We are complete.
 		// 	tt.ChunkIndex = types.MAX_CHUNKS_IN_BODY
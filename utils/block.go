@@ -8,14 +8,36 @@ import (
 )
 
 const (
-	height_2_0 = int64(422250)
-	height_2_4 = int64(633720)
-	height_2_5 = int64(812970)
+	height_2_0   = int64(422250)
+	height_2_4   = int64(633720)
+	height_2_5   = int64(812970)
+	height_2_6   = int64(1132210)
+	height_2_7   = int64(1275480)
+	height_2_7_1 = int64(1316410)
+	height_2_8   = int64(1547120)
 )
 
+// GenerateIndepHash recomputes the indep_hash of a block.
+// Blocks from height_2_6 are hashed from their signed header, "" is returned for them, see ComputeIndepHash.
 func GenerateIndepHash(b types.Block) string {
+	if b.Height >= height_2_6 {
+		return ""
+	}
+	hash, _ := ComputeIndepHash(b)
+	return hash
+}
+
+// ComputeIndepHash recomputes the indep_hash of a block, it fails on a malformed header or an unsupported height.
+// The signed headers from height_2_6 are not checked against mainnet blocks yet, see IndepHashSupported.
+func ComputeIndepHash(b types.Block) (string, error) {
 	if b.Height < height_2_0 { // not support arweave v1.0
-		return b.IndepHash
+		return b.IndepHash, nil
+	}
+	if b.Height >= height_2_8 {
+		return "", fmt.Errorf("indep_hash of height %d is not supported", b.Height)
+	}
+	if b.Height >= height_2_6 {
+		return generateSignedIndepHash(b)
 	}
 
 	bds := generateBlockDataSegment(b)
@@ -27,7 +49,7 @@ func GenerateIndepHash(b types.Block) string {
 		list = append(list, poaToList(b.Poa))
 	}
 	hash := DeepHash(list)
-	return Base64Encode(hash[:])
+	return Base64Encode(hash[:]), nil
 }
 
 func generateBlockDataSegment(b types.Block) []byte {
@@ -127,4 +149,27 @@ func formatBlockFields(b *types.Block) {
 		by, _ := json.Marshal(b.BlockSize)
 		b.BlockSize = string(by)
 	}
+
+	// 2.6+ fields, nil in older blocks
+	for _, v := range []*interface{}{
+		&b.RecallByte, &b.Reward, &b.PartitionNumber, &b.PricePerGibMinute, &b.ScheduledPricePerGibMinute,
+		&b.DebtSupply, &b.KryderPlusRateMultiplier, &b.KryderPlusRateMultiplierLatch, &b.Denomination,
+		&b.RedenominationHeight, &b.PreviousCumulativeDiff, &b.RecallByte2, &b.MerkleRebaseSupportThreshold,
+	} {
+		formatNumberField(v)
+	}
+	if info := b.NonceLimiterInfo; info != nil {
+		for _, v := range []*interface{}{
+			&info.GlobalStepNumber, &info.ZoneUpperBound, &info.NextZoneUpperBound, &info.VdfDifficulty, &info.NextVdfDifficulty,
+		} {
+			formatNumberField(v)
+		}
+	}
+}
+
+// formatNumberField converts a json number to a string
+func formatNumberField(v *interface{}) {
+	if n, ok := (*v).(json.Number); ok {
+		*v = n.String()
+	}
 }
@@ -0,0 +1,254 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/everFinance/goar/types"
+)
+
+// generateSignedIndepHash computes the indep_hash of a 2.6+ block: the sha384 of the signed hash and the signature.
+// The signed hash is the sha256 of the binary serialization of the header, see ar_block:generate_signed_hash.
+func generateSignedIndepHash(b types.Block) (string, error) {
+	signedHash, err := generateSignedHash(b)
+	if err != nil {
+		return "", err
+	}
+	sig, err := Base64Decode(b.Signature)
+	if err != nil || len(sig) == 0 {
+		return "", fmt.Errorf("invalid signature: %v", err)
+	}
+	hash := sha512.Sum384(append(signedHash, sig...))
+	return Base64Encode(hash[:]), nil
+}
+
+func generateSignedHash(b types.Block) ([]byte, error) {
+	info := b.NonceLimiterInfo
+	if info == nil {
+		return nil, errors.New("nonce_limiter_info is missing")
+	}
+	if len(b.UsdToArRate) != 2 || len(b.ScheduledUsdToArRate) != 2 {
+		return nil, errors.New("invalid usd_to_ar_rate")
+	}
+	s := &blockSerializer{}
+
+	s.bin("previous_block", b.PreviousBlock, 8)
+	s.int("timestamp", b.Timestamp, 8)
+	s.bin("nonce", b.Nonce, 16)
+	s.int("height", b.Height, 8)
+	s.int("diff", b.Diff, 16)
+	s.int("cumulative_diff", b.CumulativeDiff, 16)
+	s.int("last_retarget", b.LastRetarget, 8)
+	s.bin("hash", b.Hash, 8)
+	s.int("block_size", b.BlockSize, 16)
+	s.int("weave_size", b.WeaveSize, 16)
+	if b.RewardAddr == "unclaimed" {
+		s.bin("reward_addr", "", 8)
+	} else {
+		s.bin("reward_addr", b.RewardAddr, 8)
+	}
+	s.bin("tx_root", b.TxRoot, 8)
+	s.bin("wallet_list", b.WalletList, 8)
+	s.bin("hash_list_merkle", b.HashListMerkle, 8)
+	s.int("reward_pool", b.RewardPool, 8)
+	s.int("packing_2_5_threshold", b.Packing25Threshold, 8)
+	s.int("strict_data_split_threshold", b.StrictDataSplitThreshold, 8)
+	s.int("usd_to_ar_rate", b.UsdToArRate[0], 8)
+	s.int("usd_to_ar_rate", b.UsdToArRate[1], 8)
+	s.int("scheduled_usd_to_ar_rate", b.ScheduledUsdToArRate[0], 8)
+	s.int("scheduled_usd_to_ar_rate", b.ScheduledUsdToArRate[1], 8)
+	tags := make([]string, 0, len(b.Tags))
+	for _, tag := range b.Tags {
+		str, ok := tag.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid tag: %v", tag)
+		}
+		tags = append(tags, str)
+	}
+	s.binList("tags", tags, 16, 16)
+	s.binList("txs", b.Txs, 16, 8)
+	s.int("reward", b.Reward, 8)
+	s.int("recall_byte", b.RecallByte, 16)
+	s.bin("hash_preimage", b.HashPreimage, 8)
+	s.int("recall_byte2", b.RecallByte2, 16)
+	s.bin("reward_key", b.RewardKey, 16)
+	s.int("partition_number", b.PartitionNumber, 8)
+
+	s.fixedBin("output", info.Output, 32)
+	s.fixedInt("global_step_number", info.GlobalStepNumber, 64)
+	s.fixedBin("seed", info.Seed, 48)
+	s.fixedBin("next_seed", info.NextSeed, 48)
+	s.fixedInt("zone_upper_bound", info.ZoneUpperBound, 256)
+	s.fixedInt("next_zone_upper_bound", info.NextZoneUpperBound, 256)
+	s.bin("prev_output", info.PrevOutput, 8)
+	s.checkpoints("last_step_checkpoints", info.LastStepCheckpoints)
+	s.checkpoints("checkpoints", info.Checkpoints)
+
+	s.bin("previous_solution_hash", b.PreviousSolutionHash, 8)
+	s.int("price_per_gib_minute", b.PricePerGibMinute, 8)
+	s.int("scheduled_price_per_gib_minute", b.ScheduledPricePerGibMinute, 8)
+	s.fixedBin("reward_history_hash", b.RewardHistoryHash, 32)
+	s.int("debt_supply", b.DebtSupply, 8)
+	s.fixedInt("kryder_plus_rate_multiplier", b.KryderPlusRateMultiplier, 24)
+	s.fixedInt("kryder_plus_rate_multiplier_latch", b.KryderPlusRateMultiplierLatch, 8)
+	s.fixedInt("denomination", b.Denomination, 24)
+	s.int("redenomination_height", b.RedenominationHeight, 8)
+	s.doubleSigningProof(b.DoubleSigningProof)
+	s.int("previous_cumulative_diff", b.PreviousCumulativeDiff, 16)
+
+	if b.Height >= height_2_7 {
+		poa2 := types.POA{}
+		if b.Poa2 != nil {
+			poa2 = *b.Poa2
+		}
+		s.int("merkle_rebase_support_threshold", b.MerkleRebaseSupportThreshold, 16)
+		s.bin("poa.data_path", b.Poa.DataPath, 24)
+		s.bin("poa.tx_path", b.Poa.TxPath, 24)
+		s.bin("poa2.data_path", poa2.DataPath, 24)
+		s.bin("poa2.tx_path", poa2.TxPath, 24)
+		s.fixedBin("chunk_hash", b.ChunkHash, 32)
+		s.bin("chunk2_hash", b.Chunk2Hash, 8)
+		s.fixedBin("block_time_history_hash", b.BlockTimeHistoryHash, 32)
+	}
+	if b.Height >= height_2_7_1 {
+		s.int("vdf_difficulty", info.VdfDifficulty, 8)
+		s.int("next_vdf_difficulty", info.NextVdfDifficulty, 8)
+	}
+
+	if s.err != nil {
+		return nil, s.err
+	}
+	hash := sha256.Sum256(s.buf.Bytes())
+	return hash[:], nil
+}
+
+// blockSerializer writes the fields of a block header, the first invalid field is kept in err
+type blockSerializer struct {
+	buf bytes.Buffer
+	err error
+}
+
+func (s *blockSerializer) fail(field string, v interface{}) {
+	if s.err == nil {
+		s.err = fmt.Errorf("invalid %s: %v", field, v)
+	}
+}
+
+// size writes n on sizeBits bits, big endian
+func (s *blockSerializer) size(field string, n int, sizeBits int) {
+	if n >= 1<<sizeBits {
+		s.fail(field, fmt.Sprintf("%d bytes", n))
+		return
+	}
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(n))
+	s.buf.Write(b[8-sizeBits/8:])
+}
+
+// bin writes the base64url decoded value prefixed by its size
+func (s *blockSerializer) bin(field, value string, sizeBits int) {
+	by, err := Base64Decode(value)
+	if err != nil {
+		s.fail(field, value)
+		return
+	}
+	s.size(field, len(by), sizeBits)
+	s.buf.Write(by)
+}
+
+// fixedBin writes the base64url decoded value, that must be length bytes long
+func (s *blockSerializer) fixedBin(field, value string, length int) {
+	by, err := Base64Decode(value)
+	if err != nil || len(by) != length {
+		s.fail(field, value)
+		return
+	}
+	s.buf.Write(by)
+}
+
+// binList writes the number of values then every value prefixed by its size, in reverse order like ar_serialize
+func (s *blockSerializer) binList(field string, values []string, lenBits, sizeBits int) {
+	s.size(field, len(values), lenBits)
+	for i := len(values) - 1; i >= 0; i-- {
+		s.bin(field, values[i], sizeBits)
+	}
+}
+
+func (s *blockSerializer) checkpoints(field string, values []string) {
+	s.size(field, len(values), 16)
+	for _, v := range values {
+		s.fixedBin(field, v, 32)
+	}
+}
+
+// int writes the minimal big endian bytes of the number prefixed by their size, a missing number is empty
+func (s *blockSerializer) int(field string, v interface{}, sizeBits int) {
+	if v == nil {
+		s.size(field, 0, sizeBits)
+		return
+	}
+	n, ok := blockInt(v)
+	if !ok {
+		s.fail(field, v)
+		return
+	}
+	by := n.Bytes()
+	if len(by) == 0 {
+		by = []byte{0}
+	}
+	s.size(field, len(by), sizeBits)
+	s.buf.Write(by)
+}
+
+// fixedInt writes the number on bits bits, big endian
+func (s *blockSerializer) fixedInt(field string, v interface{}, bits int) {
+	n, ok := blockInt(v)
+	if !ok || n.BitLen() > bits {
+		s.fail(field, v)
+		return
+	}
+	by := make([]byte, bits/8)
+	n.FillBytes(by)
+	s.buf.Write(by)
+}
+
+// doubleSigningProof writes 0 without a proof, or 1 and the two signatures of the same height by the same key
+func (s *blockSerializer) doubleSigningProof(v interface{}) {
+	proof, _ := v.(map[string]interface{})
+	if len(proof) == 0 {
+		s.buf.WriteByte(0)
+		return
+	}
+	str := func(key string) string {
+		str, _ := proof[key].(string)
+		return str
+	}
+	s.buf.WriteByte(1)
+	s.fixedBin("double_signing_proof.pub_key", str("pub_key"), 512)
+	for _, i := range []string{"1", "2"} {
+		s.fixedBin("double_signing_proof.sig"+i, str("sig"+i), 512)
+		s.int("double_signing_proof.cdiff"+i, proof["cdiff"+i], 16)
+		s.int("double_signing_proof.prev_cdiff"+i, proof["prev_cdiff"+i], 16)
+		s.fixedBin("double_signing_proof.preimage"+i, str("preimage"+i), 64)
+	}
+}
+
+// blockInt parses a number of a decoded block, a string or a json number
+func blockInt(v interface{}) (*big.Int, bool) {
+	switch n := v.(type) {
+	case int64:
+		return big.NewInt(n), n >= 0
+	case string:
+		i, ok := new(big.Int).SetString(n, 10)
+		return i, ok && i.Sign() >= 0
+	case json.Number:
+		return blockInt(n.String())
+	}
+	return nil, false
+}
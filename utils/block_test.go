@@ -1,9 +1,14 @@
 package utils
 
 import (
+	"bytes"
+	"crypto/sha512"
+	"os"
+	"path/filepath"
+	"testing"
+
 	"github.com/everFinance/goar/types"
 	"github.com/stretchr/testify/assert"
-	"testing"
 )
 
 func TestIndepHash(t *testing.T) {
@@ -410,3 +415,212 @@ func TestIndepHash(t *testing.T) {
 	indepHash = GenerateIndepHash(*b)
 	assert.Equal(t, "yeQY2CnMaZknfOzeVoUhAxw1U7zSzXY3IkDlYG9_Z4FmNfCW7Gkhk3qxuT2m0lvQ", indepHash)
 }
+
+func TestDecodeBlock_2_6(t *testing.T) {
+	// the shape of a 2.7 block, with made up values
+	b, err := DecodeBlock(`{
+    "height": 1300000,
+    "indep_hash": "ih",
+    "previous_block": "pb",
+    "reward_pool": "100",
+    "weave_size": "200",
+    "block_size": "0",
+    "usd_to_ar_rate": ["1", "2"],
+    "scheduled_usd_to_ar_rate": ["1", "2"],
+    "hash_preimage": "hp",
+    "recall_byte": "123456",
+    "reward": "789",
+    "previous_solution_hash": "ps",
+    "partition_number": 12,
+    "nonce_limiter_info": {
+        "output": "out",
+        "global_step_number": 4567,
+        "seed": "s",
+        "next_seed": "ns",
+        "zone_upper_bound": 1000,
+        "next_zone_upper_bound": 2000,
+        "prev_output": "po",
+        "last_step_checkpoints": ["c1", "c2"],
+        "checkpoints": ["c3"],
+        "vdf_difficulty": "600000",
+        "next_vdf_difficulty": "600001"
+    },
+    "poa": {"option": "1", "tx_path": "tp", "data_path": "dp", "chunk": "c"},
+    "poa2": {"option": "1", "tx_path": "", "data_path": "", "chunk": ""},
+    "signature": "sig",
+    "reward_key": "rk",
+    "price_per_gib_minute": "5",
+    "scheduled_price_per_gib_minute": "6",
+    "reward_history_hash": "rhh",
+    "debt_supply": "0",
+    "kryder_plus_rate_multiplier": "1",
+    "kryder_plus_rate_multiplier_latch": "0",
+    "denomination": "1",
+    "redenomination_height": 0,
+    "double_signing_proof": {},
+    "previous_cumulative_diff": "999",
+    "merkle_rebase_support_threshold": "151066495197430",
+    "chunk_hash": "ch",
+    "block_time_history_hash": "bthh"
+}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "123456", b.RecallByte)
+	assert.Equal(t, "12", b.PartitionNumber)
+	assert.Equal(t, "0", b.RedenominationHeight)
+	assert.Nil(t, b.RecallByte2)
+	assert.Equal(t, "4567", b.NonceLimiterInfo.GlobalStepNumber)
+	assert.Equal(t, "1000", b.NonceLimiterInfo.ZoneUpperBound)
+	assert.Equal(t, []string{"c1", "c2"}, b.NonceLimiterInfo.LastStepCheckpoints)
+	assert.Equal(t, "600000", b.NonceLimiterInfo.VdfDifficulty)
+	assert.Equal(t, "1", b.Poa2.Option)
+	assert.Equal(t, "151066495197430", b.MerkleRebaseSupportThreshold)
+	assert.Equal(t, "bthh", b.BlockTimeHistoryHash)
+
+	// the made up values are not a valid header
+	assert.Equal(t, "", GenerateIndepHash(*b))
+	_, err = ComputeIndepHash(*b)
+	assert.Error(t, err)
+	assert.False(t, IndepHashSupported(b.Height))
+}
+
+// signedBlock returns a well formed 2.6+ header with made up values
+func signedBlock(height int64) types.Block {
+	bin := func(b byte, n int) string {
+		return Base64Encode(bytes.Repeat([]byte{b}, n))
+	}
+	b := types.Block{
+		Nonce:                    bin(1, 1),
+		PreviousBlock:            bin(2, 48),
+		Timestamp:                1680000000,
+		LastRetarget:             1679999000,
+		Diff:                     "115792089237316195423570985008687907853269984665640564039457584007913129639935",
+		Height:                   height,
+		Hash:                     bin(3, 32),
+		Txs:                      []string{bin(4, 32), bin(5, 32)},
+		TxRoot:                   bin(6, 32),
+		HashListMerkle:           bin(7, 48),
+		WalletList:               bin(8, 48),
+		RewardAddr:               bin(9, 32),
+		Tags:                     []interface{}{},
+		RewardPool:               "1000000",
+		WeaveSize:                "150000000000000",
+		BlockSize:                "262144",
+		CumulativeDiff:           "5000000000000",
+		UsdToArRate:              []string{"1", "10"},
+		ScheduledUsdToArRate:     []string{"1", "10"},
+		Packing25Threshold:       "0",
+		StrictDataSplitThreshold: "30607159107830",
+		HashPreimage:             bin(10, 32),
+		RecallByte:               "123456789",
+		Reward:                   "400000000000",
+		PreviousSolutionHash:     bin(11, 32),
+		PartitionNumber:          "12",
+		NonceLimiterInfo: &types.NonceLimiterInfo{
+			Output:              bin(12, 32),
+			GlobalStepNumber:    "4567",
+			Seed:                bin(13, 48),
+			NextSeed:            bin(14, 48),
+			ZoneUpperBound:      "150000000000000",
+			NextZoneUpperBound:  "150000000000000",
+			PrevOutput:          bin(15, 32),
+			LastStepCheckpoints: []string{bin(16, 32), bin(17, 32)},
+			Checkpoints:         []string{bin(18, 32)},
+			VdfDifficulty:       "600000",
+			NextVdfDifficulty:   "600000",
+		},
+		Poa:                           types.POA{Option: "1", TxPath: bin(19, 64), DataPath: bin(20, 64), Chunk: bin(21, 256)},
+		Signature:                     bin(22, 512),
+		RewardKey:                     bin(23, 512),
+		PricePerGibMinute:             "5",
+		ScheduledPricePerGibMinute:    "6",
+		RewardHistoryHash:             bin(24, 32),
+		DebtSupply:                    "0",
+		KryderPlusRateMultiplier:      "1",
+		KryderPlusRateMultiplierLatch: "0",
+		Denomination:                  "1",
+		RedenominationHeight:          "0",
+		PreviousCumulativeDiff:        "4999999999999",
+		MerkleRebaseSupportThreshold:  "151066495197430",
+		ChunkHash:                     bin(25, 32),
+		BlockTimeHistoryHash:          bin(26, 32),
+	}
+	return b
+}
+
+func TestComputeIndepHash_2_6(t *testing.T) {
+	for _, height := range []int64{height_2_6, height_2_7, height_2_7_1} {
+		b := signedBlock(height)
+		indepHash, err := ComputeIndepHash(b)
+		assert.NoError(t, err)
+
+		// the signature is hashed with the signed hash of the header
+		signedHash, err := generateSignedHash(b)
+		assert.NoError(t, err)
+		sig, _ := Base64Decode(b.Signature)
+		hash := sha512.Sum384(append(signedHash, sig...))
+		assert.Equal(t, Base64Encode(hash[:]), indepHash)
+
+		// every signed field changes it
+		for _, tamper := range []func(b *types.Block){
+			func(b *types.Block) { b.Signature = Base64Encode(bytes.Repeat([]byte{1}, 512)) },
+			func(b *types.Block) { b.Timestamp++ },
+			func(b *types.Block) { b.Txs = []string{b.Txs[1], b.Txs[0]} },
+			func(b *types.Block) { b.RecallByte2 = "1" },
+			func(b *types.Block) { b.PreviousCumulativeDiff = "1" },
+			func(b *types.Block) {
+				info := *b.NonceLimiterInfo
+				info.Checkpoints = nil
+				b.NonceLimiterInfo = &info
+			},
+		} {
+			tampered := b
+			tamper(&tampered)
+			hash, err := ComputeIndepHash(tampered)
+			assert.NoError(t, err)
+			assert.NotEqual(t, indepHash, hash)
+		}
+	}
+
+	// the 2.7 fields are only signed from height_2_7
+	b := signedBlock(height_2_6)
+	hash, _ := ComputeIndepHash(b)
+	b.ChunkHash = ""
+	tampered, err := ComputeIndepHash(b)
+	assert.NoError(t, err)
+	assert.Equal(t, hash, tampered)
+	b.Height = height_2_7
+	_, err = ComputeIndepHash(b)
+	assert.Error(t, err)
+
+	// malformed headers
+	for _, tamper := range []func(b *types.Block){
+		func(b *types.Block) { b.Signature = "" },
+		func(b *types.Block) { b.NonceLimiterInfo = nil },
+		func(b *types.Block) { b.Diff = "-1" },
+		func(b *types.Block) { b.RewardHistoryHash = Base64Encode([]byte{1}) },
+		func(b *types.Block) { b.KryderPlusRateMultiplier = "16777216" },
+	} {
+		b := signedBlock(height_2_7)
+		tamper(&b)
+		_, err := ComputeIndepHash(b)
+		assert.Error(t, err)
+	}
+	_, err = ComputeIndepHash(signedBlock(height_2_8))
+	assert.Error(t, err)
+}
+
+// TestComputeIndepHash_Testdata recomputes the indep_hash of the mainnet blocks of testdata
+func TestComputeIndepHash_Testdata(t *testing.T) {
+	files, err := filepath.Glob("testdata/block_*.json")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, files)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		b, err := DecodeBlock(string(data))
+		assert.NoError(t, err)
+		indepHash, err := ComputeIndepHash(*b)
+		assert.NoError(t, err, file)
+		assert.Equal(t, b.IndepHash, indepHash, file)
+	}
+}
@@ -60,7 +60,7 @@ func NewBundle(items ...types.BundleItem) (*types.Bundle, error) {
 func NewBundleStream(items ...types.BundleItem) (*types.Bundle, error) {
 	headers := make([]byte, 0) // length is 64 * len(items)
 	headers = append(headers, LongTo32ByteArray(len(items))...)
-	dataReader, err := os.CreateTemp(".", "bundleData-")
+	dataReader, err := CreateTemp("", "bundleData-")
 	if err != nil {
 		return nil, err
 	}
@@ -188,25 +188,9 @@ func DecodeBundleStream(bundleData *os.File) (*types.Bundle, error) {
 		}
 		itemBinaryLength := ByteArrayToLong(headerByte[:32])
 		id := Base64Encode(headerByte[32:64])
-		itemReader, err := os.CreateTemp(".", "bundleItem-")
-		if err != nil {
-			return nil, errors.New("CreateTempItemFile error")
-		}
-		_, err = bundleData.Seek(int64(bundleItemStart), 0)
-		if err != nil {
-			return nil, errors.New("seek bundleData failed")
-		}
-		n, err1 := io.CopyN(itemReader, bundleData, int64(itemBinaryLength))
-		if int(n) < itemBinaryLength || err1 != nil {
-			return nil, errors.New("binary length incorrect")
-		}
-		_, err = itemReader.Seek(0, 0)
-		if err != nil {
-			return nil, errors.New("seek itemData failed")
-		}
+		// the item is decoded straight from its section of bundleData, no temp copy is needed
+		itemReader := io.NewSectionReader(bundleData, int64(bundleItemStart), int64(itemBinaryLength))
 		bundleItem, err2 := DecodeBundleItemStream(itemReader)
-		itemReader.Close()
-		os.Remove(itemReader.Name())
 		if err2 != nil {
 			return nil, err2
 		}
@@ -404,7 +388,7 @@ func DecodeBundleItemStream(itemBinary io.Reader) (*types.BundleItem, error) {
 		}
 		tags = tgs
 	}
-	dataReader, err := os.CreateTemp(".", "itemData-")
+	dataReader, err := CreateTemp("", "itemData-")
 	if err != nil {
 		return nil, err
 	}
@@ -857,7 +841,7 @@ func SubmitItemToMU(item types.BundleItem, muUrl string) ([]byte, error) {
 		return nil, err
 	}
 	defer resp.Body.Close()
-	fmt.Printf("resp code:%v\n", resp.StatusCode)
+	DefaultLogger().Debug("submit item to mu", "itemId", item.Id, "statusCode", resp.StatusCode)
 	// json unmarshal
 	body, err := io.ReadAll(resp.Body)
 	return body, err
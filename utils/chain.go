@@ -0,0 +1,67 @@
+package utils
+
+import (
+	"fmt"
+
+	"github.com/everFinance/goar/types"
+)
+
+// IndepHashSupported reports whether GenerateIndepHash can recompute the indep_hash of a block at height.
+// The signed headers from height_2_6 are reported unverified until they are checked against mainnet blocks.
+func IndepHashSupported(height int64) bool {
+	return height >= height_2_0 && height < height_2_6
+}
+
+// VerifyBlockChain checks that blocks, sorted by height, form a chain: the indep_hash of every block is recomputed,
+// and linked to the next block by its previous_block and height.
+// hashList are the indep_hashes of the same heights, eg: from GetBlockHashList, nil to skip this check.
+func VerifyBlockChain(blocks []*types.Block, hashList []string) *types.ChainReport {
+	report := &types.ChainReport{Unverified: make([]int64, 0), Issues: make([]types.BlockIssue, 0)}
+	if len(blocks) == 0 {
+		return report
+	}
+	report.From, report.To = blocks[0].Height, blocks[len(blocks)-1].Height
+	if hashList != nil && len(hashList) != len(blocks) {
+		report.Issues = append(report.Issues, types.BlockIssue{
+			Height:   report.From,
+			Check:    types.CheckHashList,
+			Expected: fmt.Sprintf("%d hashes", len(blocks)),
+			Actual:   fmt.Sprintf("%d hashes", len(hashList)),
+		})
+		hashList = nil
+	}
+
+	for i, b := range blocks {
+		issues := len(report.Issues)
+		addIssue := func(check, expected, actual string) {
+			report.Issues = append(report.Issues, types.BlockIssue{Height: b.Height, Check: check, Expected: expected, Actual: actual})
+		}
+
+		if IndepHashSupported(b.Height) {
+			if hash, err := ComputeIndepHash(*b); err != nil { // a malformed header
+				addIssue(types.CheckIndepHash, err.Error(), b.IndepHash)
+			} else if hash != b.IndepHash {
+				addIssue(types.CheckIndepHash, hash, b.IndepHash)
+			}
+		} else {
+			report.Unverified = append(report.Unverified, b.Height)
+		}
+		if i > 0 {
+			prev := blocks[i-1]
+			if b.Height != prev.Height+1 {
+				addIssue(types.CheckHeight, fmt.Sprintf("%d", prev.Height+1), fmt.Sprintf("%d", b.Height))
+			}
+			if b.PreviousBlock != prev.IndepHash {
+				addIssue(types.CheckPrevious, prev.IndepHash, b.PreviousBlock)
+			}
+		}
+		if hashList != nil && hashList[i] != b.IndepHash {
+			addIssue(types.CheckHashList, hashList[i], b.IndepHash)
+		}
+
+		if len(report.Issues) == issues && IndepHashSupported(b.Height) {
+			report.Verified++
+		}
+	}
+	return report
+}
@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"testing"
+
+	"github.com/everFinance/goar/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// loadBlock decodes the block fixture testdata/block_<height>.json
+func loadBlock(t *testing.T, height string) *types.Block {
+	data, err := os.ReadFile("testdata/block_" + height + ".json")
+	assert.NoError(t, err)
+	b, err := DecodeBlock(string(data))
+	assert.NoError(t, err)
+	return b
+}
+
+func TestVerifyBlockChain(t *testing.T) {
+	b1 := loadBlock(t, "633719")
+	b2 := loadBlock(t, "633720")
+
+	report := VerifyBlockChain([]*types.Block{b1, b2}, []string{b1.IndepHash, b2.IndepHash})
+	assert.True(t, report.Valid())
+	assert.Equal(t, 2, report.Verified)
+	assert.Equal(t, int64(633719), report.From)
+	assert.Equal(t, int64(633720), report.To)
+
+	// a tampered block breaks its indep_hash, and the hash list
+	tampered := *b2
+	tampered.RewardAddr = b1.RewardAddr
+	tampered.IndepHash = "fake"
+	report = VerifyBlockChain([]*types.Block{b1, &tampered}, []string{b1.IndepHash, b2.IndepHash})
+	assert.False(t, report.Valid())
+	assert.Equal(t, 1, report.Verified)
+	checks := make([]string, 0)
+	for _, issue := range report.Issues {
+		assert.Equal(t, int64(633720), issue.Height)
+		checks = append(checks, issue.Check)
+	}
+	assert.Equal(t, []string{types.CheckIndepHash, types.CheckHashList}, checks)
+
+	// the blocks are not consecutive
+	report = VerifyBlockChain([]*types.Block{b2, b1}, nil)
+	checks = make([]string, 0)
+	for _, issue := range report.Issues {
+		checks = append(checks, issue.Check)
+	}
+	assert.Equal(t, []string{types.CheckHeight, types.CheckPrevious}, checks)
+
+	// blocks of unsupported heights are not verified
+	report = VerifyBlockChain([]*types.Block{{Height: 1, IndepHash: "a"}, {Height: 2, IndepHash: "b", PreviousBlock: "a"}}, nil)
+	assert.True(t, report.Valid())
+	assert.Equal(t, 0, report.Verified)
+	assert.Equal(t, []int64{1, 2}, report.Unverified)
+
+	// nor the signed headers from height_2_6
+	b3 := signedBlock(height_2_6)
+	report = VerifyBlockChain([]*types.Block{&b3}, nil)
+	assert.True(t, report.Valid())
+	assert.Equal(t, []int64{height_2_6}, report.Unverified)
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/inconshreveable/log15"
+)
+
+// Logger is the structured logger used by goar.
+// ctx are alternating keys and values, as in log15 and log/slog.
+type Logger interface {
+	Debug(msg string, ctx ...interface{})
+	Info(msg string, ctx ...interface{})
+	Warn(msg string, ctx ...interface{})
+	Error(msg string, ctx ...interface{})
+	// With returns a logger adding ctx to every record
+	With(ctx ...interface{}) Logger
+}
+
+var (
+	loggerLock    sync.RWMutex
+	defaultLogger Logger = newStderrLogger()
+)
+
+// the default logger writes info and above as logfmt to stderr, goar never writes to stdout
+func newStderrLogger() Logger {
+	l := log15.New("module", "goar")
+	l.SetHandler(log15.LvlFilterHandler(log15.LvlInfo, log15.StreamHandler(os.Stderr, log15.LogfmtFormat())))
+	return NewLog15Logger(l)
+}
+
+// SetLogger replaces the logger of the package level functions and of the clients without a logger of their own
+func SetLogger(l Logger) {
+	if l == nil {
+		l = NopLogger()
+	}
+	loggerLock.Lock()
+	defaultLogger = l
+	loggerLock.Unlock()
+}
+
+// DefaultLogger returns the logger set by SetLogger
+func DefaultLogger() Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+	return defaultLogger
+}
+
+type log15Logger struct {
+	l log15.Logger
+}
+
+// NewLog15Logger adapts a log15 logger
+func NewLog15Logger(l log15.Logger) Logger {
+	return log15Logger{l: l}
+}
+
+func (l log15Logger) Debug(msg string, ctx ...interface{}) { l.l.Debug(msg, ctx...) }
+func (l log15Logger) Info(msg string, ctx ...interface{})  { l.l.Info(msg, ctx...) }
+func (l log15Logger) Warn(msg string, ctx ...interface{})  { l.l.Warn(msg, ctx...) }
+func (l log15Logger) Error(msg string, ctx ...interface{}) { l.l.Error(msg, ctx...) }
+func (l log15Logger) With(ctx ...interface{}) Logger       { return log15Logger{l: l.l.New(ctx...)} }
+
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// NewSlogLogger adapts a log/slog logger, the records are logged with context.Background()
+func NewSlogLogger(l *slog.Logger) Logger {
+	return slogLogger{l: l}
+}
+
+func (l slogLogger) Debug(msg string, ctx ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelDebug, msg, ctx...)
+}
+func (l slogLogger) Info(msg string, ctx ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelInfo, msg, ctx...)
+}
+func (l slogLogger) Warn(msg string, ctx ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelWarn, msg, ctx...)
+}
+func (l slogLogger) Error(msg string, ctx ...interface{}) {
+	l.l.Log(context.Background(), slog.LevelError, msg, ctx...)
+}
+func (l slogLogger) With(ctx ...interface{}) Logger { return slogLogger{l: l.l.With(ctx...)} }
+
+type nopLogger struct{}
+
+// NopLogger discards every record
+func NopLogger() Logger {
+	return nopLogger{}
+}
+
+func (nopLogger) Debug(msg string, ctx ...interface{}) {}
+func (nopLogger) Info(msg string, ctx ...interface{})  {}
+func (nopLogger) Warn(msg string, ctx ...interface{})  {}
+func (nopLogger) Error(msg string, ctx ...interface{}) {}
+func (n nopLogger) With(ctx ...interface{}) Logger     { return n }
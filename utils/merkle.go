@@ -3,6 +3,7 @@ package utils
 import (
 	"bytes"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -334,6 +335,9 @@ func ValidatePath(id []byte, dest, leftBound, rightBound int, path []byte) (*Val
 		return nil, false
 	}
 
+	if len(path) < 2*types.HASH_SIZE+types.NOTE_SIZE {
+		return nil, false
+	}
 	left := path[0:types.HASH_SIZE]
 	right := path[len(left) : len(left)+types.HASH_SIZE]
 	offsetBuffer := path[len(left)+len(right) : len(left)+len(right)+types.NOTE_SIZE]
@@ -384,3 +388,25 @@ func Hash(data [][]byte) []byte {
 	byte32 := sha256.Sum256(ConcatBuffer(data...))
 	return byte32[:]
 }
+
+// ValidateChunk checks a chunk downloaded from /chunk against the data_root of its transaction:
+// dataPath must prove the chunk at offset (relative to the start of the tx data) and hash to the chunk's SHA-256
+func ValidateChunk(dataRoot []byte, dataSize, offset int, dataPath []byte, chunk []byte) (*ValidateResult, error) {
+	if len(dataPath) < types.HASH_SIZE+types.NOTE_SIZE {
+		return nil, errors.New("data_path too short")
+	}
+	result, ok := ValidatePath(dataRoot, offset, 0, dataSize, dataPath)
+	if !ok {
+		return nil, errors.New("invalid data_path")
+	}
+
+	leaf := dataPath[len(dataPath)-types.HASH_SIZE-types.NOTE_SIZE:]
+	chunkHash := sha256.Sum256(chunk)
+	if !bytes.Equal(leaf[:types.HASH_SIZE], chunkHash[:]) {
+		return nil, errors.New("chunk hash mismatch")
+	}
+	if len(chunk) != result.ChunkSize {
+		return nil, fmt.Errorf("chunk size mismatch, expect: %d, got: %d", result.ChunkSize, len(chunk))
+	}
+	return result, nil
+}
@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"os"
+	"sync"
+)
+
+var (
+	tempDirLock sync.RWMutex
+	tempDir     string
+)
+
+// SetTempDir sets the directory of the temp files created by goar, os.TempDir() is used when dir is empty
+func SetTempDir(dir string) {
+	tempDirLock.Lock()
+	tempDir = dir
+	tempDirLock.Unlock()
+}
+
+// TempDir returns the directory set by SetTempDir
+func TempDir() string {
+	tempDirLock.RLock()
+	defer tempDirLock.RUnlock()
+	return tempDir
+}
+
+// CreateTemp creates a temp file in dir, or in TempDir() when dir is empty.
+// it's caller's responsibility to delete the file
+func CreateTemp(dir, pattern string) (*os.File, error) {
+	if dir == "" {
+		dir = TempDir()
+	}
+	return os.CreateTemp(dir, pattern)
+}
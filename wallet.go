@@ -16,6 +16,9 @@ import (
 type Wallet struct {
 	Client *Client
 	Signer *Signer
+
+	feeStrategy   FeeStrategy
+	skipPreflight bool
 }
 
 // proxyUrl: option
@@ -42,6 +45,34 @@ func NewWallet(b []byte, clientUrl string, proxyUrl ...string) (w *Wallet, err e
 	return
 }
 
+// WithContext returns a shallow copy of the wallet whose client is bound to ctx
+func (w *Wallet) WithContext(ctx context.Context) *Wallet {
+	w2 := *w
+	w2.Client = w.Client.WithContext(ctx)
+	return &w2
+}
+
+// SetLogger sets the logger of the wallet's client
+func (w *Wallet) SetLogger(l Logger) {
+	w.Client.SetLogger(l)
+}
+
+// SetFeeStrategy sets how the Send methods compute the reward of their transactions,
+// their speedFactor is applied on top of it, under the Max of a Ceiling. nil pays the price quoted by the gateway.
+func (w *Wallet) SetFeeStrategy(strategy FeeStrategy) {
+	w.feeStrategy = strategy
+}
+
+// estimateReward returns the reward of a tx of dataSize bytes, target is optional.
+// The speedFactor applies to the reward of the fee strategy, a Ceiling still caps it.
+func (w *Wallet) estimateReward(dataSize int64, target string, speedFactor int64) (string, error) {
+	fee, err := NewFeeEstimator(w.Client, withSpeedUp(w.feeStrategy, speedFactor)).Estimate(dataSize, target)
+	if err != nil {
+		return "", err
+	}
+	return fee.Reward.String(), nil
+}
+
 func (w *Wallet) Owner() string {
 	return w.Signer.Owner()
 }
@@ -59,7 +90,7 @@ func (w *Wallet) SendWinston(amount *big.Int, target string, tags []types.Tag) (
 }
 
 func (w *Wallet) SendWinstonSpeedUp(amount *big.Int, target string, tags []types.Tag, speedFactor int64) (types.Transaction, error) {
-	reward, err := w.Client.GetTransactionPrice(0, &target)
+	reward, err := w.estimateReward(0, target, speedFactor)
 	if err != nil {
 		return types.Transaction{}, err
 	}
@@ -71,7 +102,7 @@ func (w *Wallet) SendWinstonSpeedUp(amount *big.Int, target string, tags []types
 		Tags:     utils.TagsEncode(tags),
 		Data:     "",
 		DataSize: "0",
-		Reward:   fmt.Sprintf("%d", reward*(100+speedFactor)/100),
+		Reward:   reward,
 	}
 
 	return w.SendTransaction(tx)
@@ -88,7 +119,7 @@ func (w *Wallet) SendDataStream(data *os.File, tags []types.Tag) (types.Transact
 // SendDataSpeedUp set speedFactor for speed up
 // eg: speedFactor = 10, reward = 1.1 * reward
 func (w *Wallet) SendDataSpeedUp(data []byte, tags []types.Tag, speedFactor int64) (types.Transaction, error) {
-	reward, err := w.Client.GetTransactionPrice(len(data), nil)
+	reward, err := w.estimateReward(int64(len(data)), "", speedFactor)
 	if err != nil {
 		return types.Transaction{}, err
 	}
@@ -100,7 +131,7 @@ func (w *Wallet) SendDataSpeedUp(data []byte, tags []types.Tag, speedFactor int6
 		Tags:     utils.TagsEncode(tags),
 		Data:     utils.Base64Encode(data),
 		DataSize: fmt.Sprintf("%d", len(data)),
-		Reward:   fmt.Sprintf("%d", reward*(100+speedFactor)/100),
+		Reward:   reward,
 	}
 
 	return w.SendTransaction(tx)
@@ -111,7 +142,7 @@ func (w *Wallet) SendDataStreamSpeedUp(data *os.File, tags []types.Tag, speedFac
 	if err != nil {
 		return types.Transaction{}, err
 	}
-	reward, err := w.Client.GetTransactionPrice(int(fileInfo.Size()), nil)
+	reward, err := w.estimateReward(fileInfo.Size(), "", speedFactor)
 	if err != nil {
 		return types.Transaction{}, err
 	}
@@ -124,14 +155,13 @@ func (w *Wallet) SendDataStreamSpeedUp(data *os.File, tags []types.Tag, speedFac
 		Data:       "",
 		DataReader: data,
 		DataSize:   fmt.Sprintf("%d", fileInfo.Size()),
-		Reward:     fmt.Sprintf("%d", reward*(100+speedFactor)/100),
+		Reward:     reward,
 	}
 
 	return w.SendTransaction(tx)
 }
 
 func (w *Wallet) SendDataConcurrentSpeedUp(ctx context.Context, concurrentNum int, data interface{}, tags []types.Tag, speedFactor int64) (types.Transaction, error) {
-	var reward int64
 	var dataLen int
 	isByteArr := true
 	if _, isByteArr = data.([]byte); isByteArr {
@@ -143,7 +173,7 @@ func (w *Wallet) SendDataConcurrentSpeedUp(ctx context.Context, concurrentNum in
 		}
 		dataLen = int(fileInfo.Size())
 	}
-	reward, err := w.Client.GetTransactionPrice(dataLen, nil)
+	reward, err := w.WithContext(ctx).estimateReward(int64(dataLen), "", speedFactor)
 	if err != nil {
 		return types.Transaction{}, err
 	}
@@ -154,7 +184,7 @@ func (w *Wallet) SendDataConcurrentSpeedUp(ctx context.Context, concurrentNum in
 		Quantity: "0",
 		Tags:     utils.TagsEncode(tags),
 		DataSize: fmt.Sprintf("%d", dataLen),
-		Reward:   fmt.Sprintf("%d", reward*(100+speedFactor)/100),
+		Reward:   reward,
 	}
 
 	if isByteArr {
@@ -168,6 +198,11 @@ func (w *Wallet) SendDataConcurrentSpeedUp(ctx context.Context, concurrentNum in
 
 // SendTransaction: if send success, should return pending
 func (w *Wallet) SendTransaction(tx *types.Transaction) (types.Transaction, error) {
+	if !w.skipPreflight {
+		if err := w.Preflight(tx); err != nil {
+			return types.Transaction{}, err
+		}
+	}
 	uploader, err := w.getUploader(tx)
 	if err != nil {
 		return types.Transaction{}, err
@@ -177,7 +212,12 @@ func (w *Wallet) SendTransaction(tx *types.Transaction) (types.Transaction, erro
 }
 
 func (w *Wallet) SendTransactionConcurrent(ctx context.Context, concurrentNum int, tx *types.Transaction) (types.Transaction, error) {
-	uploader, err := w.getUploader(tx)
+	if !w.skipPreflight {
+		if err := w.WithContext(ctx).Preflight(tx); err != nil {
+			return types.Transaction{}, err
+		}
+	}
+	uploader, err := w.WithContext(ctx).getUploader(tx)
 	if err != nil {
 		return types.Transaction{}, err
 	}
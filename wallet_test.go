@@ -2,6 +2,7 @@ package goar
 
 import (
 	"encoding/base64"
+	"math/big"
 	"os"
 
 	"github.com/everFinance/goar/types"
@@ -104,6 +105,34 @@ func TestWallet_SendDataSpeedUp02(t *testing.T) {
 	// t.Logf("tx hash: %s", tx.ID)
 }
 
+func TestWallet_EstimateRewardSpeedUp(t *testing.T) {
+	srv := mockPrices(t)
+	defer srv.Close()
+	w := &Wallet{Client: NewClient(srv.URL), Signer: testWallet.Signer}
+
+	reward, err := w.estimateReward(100, "", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "1501", reward)
+
+	// the ceiling caps the sped up reward
+	w.SetFeeStrategy(Ceiling{Strategy: FixedMultiplier(10), Max: big.NewInt(1200)})
+	reward, err = w.estimateReward(100, "", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "1200", reward)
+	reward, err = w.estimateReward(100, "", 5)
+	assert.NoError(t, err)
+	assert.Equal(t, "1156", reward)
+	w.SetFeeStrategy(&Ceiling{Max: big.NewInt(1200)})
+	reward, err = w.estimateReward(100, "", 50)
+	assert.NoError(t, err)
+	assert.Equal(t, "1200", reward)
+
+	// the quoted price is above the ceiling
+	w.SetFeeStrategy(Ceiling{Max: big.NewInt(1000)})
+	_, err = w.estimateReward(100, "", 50)
+	assert.ErrorIs(t, err, ErrFeeCeiling)
+}
+
 func Test_SendPstTransfer(t *testing.T) {
 	// w, err := NewWalletFromPath("./wallet/account1.json","https://arweave.net")
 	// assert.NoError(t, err)